@@ -0,0 +1,177 @@
+package pool
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// PoolStatus is state of the
+// pool exposed by admin handler
+type PoolStatus struct {
+	Name      string          `json:"name"`
+	Discovery DiscoveryStatus `json:"discovery"`
+	ListSnapshot
+}
+
+// AdminHandler is http.Handler exposing state of the registered
+// pools and endpoints to manually manage their services:
+//
+//	GET  /pools                               list all the pools
+//	GET  /pools/{pool}                        get the pool
+//	POST /pools/{pool}/discover               force rediscovery
+//	POST /pools/{pool}/services/{id}/jail     jail the service until it is released
+//	POST /pools/{pool}/services/{id}/release  release the service from jail or drain
+//	POST /pools/{pool}/services/{id}/drain    stop selecting the service
+//
+// Use http.StripPrefix to mount it under custom path
+type AdminHandler struct {
+	mu    sync.RWMutex
+	pools map[string]IServicesPool
+
+	mux *http.ServeMux
+}
+
+// NewAdminHandler create new AdminHandler
+// exposing all given pools
+func NewAdminHandler(pools ...IServicesPool) *AdminHandler {
+	h := &AdminHandler{
+		pools: make(map[string]IServicesPool),
+		mux:   http.NewServeMux(),
+	}
+
+	for _, p := range pools {
+		h.Register(p)
+	}
+
+	h.mux.HandleFunc("GET /pools", h.listPools)
+	h.mux.HandleFunc("GET /pools/{pool}", h.getPool)
+	h.mux.HandleFunc("POST /pools/{pool}/discover", h.discover)
+	h.mux.HandleFunc("POST /pools/{pool}/services/{id}/jail", h.serviceAction(IServicesList.Jail))
+	h.mux.HandleFunc("POST /pools/{pool}/services/{id}/release", h.serviceAction(IServicesList.Release))
+	h.mux.HandleFunc("POST /pools/{pool}/services/{id}/drain", h.serviceAction(IServicesList.Drain))
+
+	return h
+}
+
+// Register add given pool to the handler,
+// pool with the same name is replaced
+func (h *AdminHandler) Register(p IServicesPool) {
+	if p == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.pools[p.Name()] = p
+}
+
+// ServeHTTP implements http.Handler
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// pool return registered pool with given name
+func (h *AdminHandler) pool(name string) (IServicesPool, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	p, ok := h.pools[name]
+	if !ok {
+		return nil, ErrPoolNotFound{Name: name}
+	}
+	return p, nil
+}
+
+func (h *AdminHandler) listPools(w http.ResponseWriter, _ *http.Request) {
+	h.mu.RLock()
+	pools := make([]IServicesPool, 0, len(h.pools))
+	for _, p := range h.pools {
+		pools = append(pools, p)
+	}
+	h.mu.RUnlock()
+
+	sort.Slice(pools, func(i, j int) bool { return pools[i].Name() < pools[j].Name() })
+
+	statuses := make([]PoolStatus, 0, len(pools))
+	for _, p := range pools {
+		statuses = append(statuses, poolStatus(p))
+	}
+
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (h *AdminHandler) getPool(w http.ResponseWriter, r *http.Request) {
+	p, err := h.pool(r.PathValue("pool"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, poolStatus(p))
+}
+
+func (h *AdminHandler) discover(w http.ResponseWriter, r *http.Request) {
+	p, err := h.pool(r.PathValue("pool"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := p.DiscoverServices(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, poolStatus(p))
+}
+
+// serviceAction return handler calling given
+// list action with service ID from the path
+func (h *AdminHandler) serviceAction(action func(IServicesList, string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := h.pool(r.PathValue("pool"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if err := action(p.List(), r.PathValue("id")); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, poolStatus(p))
+	}
+}
+
+// poolStatus collect status of given pool
+func poolStatus(p IServicesPool) PoolStatus {
+	return PoolStatus{
+		Name:         p.Name(),
+		Discovery:    p.DiscoveryStatus(),
+		ListSnapshot: p.List().Snapshot(),
+	}
+}
+
+// writeJSON write given value
+// as JSON response body
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError write given error as
+// JSON response with matching code
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if errors.As(err, &ErrPoolNotFound{}) || errors.As(err, &ErrServiceNotFound{}) {
+		code = http.StatusNotFound
+	}
+
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,64 @@
+package pool
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminHandler(t *testing.T) {
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1}, "http://a", "http://b")
+
+	handler := NewAdminHandler(pool)
+
+	do := func(method, path string, code int) PoolStatus {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		if rec.Code != code {
+			t.Fatalf("%s %s: want code %d, got %d: %s", method, path, code, rec.Code, rec.Body)
+		}
+
+		var status PoolStatus
+		_ = json.Unmarshal(rec.Body.Bytes(), &status)
+		return status
+	}
+
+	status := do(http.MethodPost, "/pools/TestServicePool/discover", http.StatusOK)
+	if status.Healthy != 2 || status.Discovery.LastSuccess.IsZero() {
+		t.Fatalf("want 2 healthy services after discovery, got %+v", status)
+	}
+	if status.Services[0].Stats.LastCheck.IsZero() {
+		t.Errorf("healthcheck time was not reported")
+	}
+
+	drained := status.Services[0].ID
+	status = do(http.MethodPost, "/pools/TestServicePool/services/"+drained+"/drain", http.StatusOK)
+	if !status.Services[0].Draining {
+		t.Errorf("service is not reported as draining")
+	}
+
+	for i := 0; i < 4; i++ {
+		if srv := pool.NextService(); srv == nil || srv.ID() == drained {
+			t.Fatalf("drained service must be skipped by Next")
+		}
+	}
+
+	status = do(http.MethodPost, "/pools/TestServicePool/services/"+drained+"/release", http.StatusOK)
+	if status.Services[0].Draining {
+		t.Errorf("service is still draining after release")
+	}
+
+	do(http.MethodPost, "/pools/TestServicePool/services/unknown/jail", http.StatusNotFound)
+	do(http.MethodGet, "/pools/unknown", http.StatusNotFound)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/pools", nil))
+
+	var pools []PoolStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &pools); err != nil || len(pools) != 1 {
+		t.Errorf("want 1 pool listed, got %d (%v)", len(pools), err)
+	}
+}
@@ -0,0 +1,165 @@
+package pool
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// MaxConcurrencyMetaKey is service metadata key overriding
+// max number of acquired slots of the service
+const MaxConcurrencyMetaKey = "max_concurrency"
+
+// Acquire returns next healthy service which is not saturated and
+// not rate limited and takes its concurrency slot until release is
+// called, if all the services are saturated or rate limited it
+// waits in the queue when it is enabled.
+// Nil service and error are returned if there are no healthy services
+func (l *ServicesList) Acquire(ctx context.Context, skip func(srv service.IService) bool) (service.IService, func(), error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return l.acquireNext(ctx, skip, true)
+}
+
+// TryAcquire returns next healthy service which is not saturated and
+// not rate limited and takes its concurrency slot until release is
+// called, it never waits in the queue and ErrServicesSaturated is
+// returned at once if all the services are saturated or rate limited.
+// Nil service and error are returned if there are no healthy services
+func (l *ServicesList) TryAcquire(skip func(srv service.IService) bool) (service.IService, func(), error) {
+	return l.acquireNext(context.Background(), skip, false)
+}
+
+// acquireNext returns next service which is not skipped by given
+// function taking its concurrency slot, if all the services are
+// saturated or rate limited it optionally waits in the queue
+func (l *ServicesList) acquireNext(ctx context.Context, skip func(srv service.IService) bool, queue bool) (service.IService, func(), error) {
+	l.mu.Lock()
+
+	srv, saturated := l.next(skip)
+	if srv != nil {
+		release := l.acquire(srv)
+		l.mu.Unlock()
+		return srv, release, nil
+	}
+
+	// there are no healthy services at all
+	if !saturated {
+		l.mu.Unlock()
+		return nil, nil, nil
+	}
+
+	if !queue || l.QueueSize <= 0 {
+		l.mu.Unlock()
+		return nil, nil, ErrServicesSaturated{Pool: l.serviceName}
+	}
+	if l.waiting >= l.QueueSize {
+		l.mu.Unlock()
+		return nil, nil, ErrQueueFull{Pool: l.serviceName, Size: l.QueueSize}
+	}
+
+	l.waiting++
+	defer func() {
+		l.mu.Lock()
+		l.waiting--
+		l.mu.Unlock()
+	}()
+
+	if l.QueueTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.QueueTimeout)
+		defer cancel()
+	}
+
+	for {
+		freed := l.slotFreed
+		wait := l.rateWait(time.Now())
+		l.mu.Unlock()
+
+		// rate limited services get tokens without any signal
+		var refilled <-chan time.Time
+		if wait > 0 {
+			refilled = time.After(wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ErrQueueTimeout{Pool: l.serviceName, Err: ctx.Err()}
+		case <-freed:
+		case <-refilled:
+		}
+
+		l.mu.Lock()
+
+		srv, saturated := l.next(skip)
+		if srv != nil {
+			release := l.acquire(srv)
+			l.mu.Unlock()
+			return srv, release, nil
+		}
+
+		if !saturated {
+			l.mu.Unlock()
+			return nil, nil, nil
+		}
+	}
+}
+
+// InFlight return number of acquired
+// slots of the service with given ID
+func (l *ServicesList) InFlight(id string) int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.inflight[id]
+}
+
+// acquire take slot of given service and return function
+// to release it, must be called under the lock
+func (l *ServicesList) acquire(srv service.IService) func() {
+	id := srv.ID()
+	l.inflight[id]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			if l.inflight[id]--; l.inflight[id] <= 0 {
+				delete(l.inflight, id)
+			}
+			l.notifySlots()
+		})
+	}
+}
+
+// notifySlots wake up all the Acquire calls waiting in the queue, it
+// is called when slot is released or set of selectable services is
+// changed, must be called under the lock
+func (l *ServicesList) notifySlots() {
+	close(l.slotFreed)
+	l.slotFreed = make(chan struct{})
+}
+
+// isSaturated check if all slots of given service
+// are acquired, must be called under the lock
+func (l *ServicesList) isSaturated(srv service.IService) bool {
+	limit := l.maxConcurrency(srv)
+	return limit > 0 && l.inflight[srv.ID()] >= limit
+}
+
+// maxConcurrency return max number of acquired slots of given
+// service from its metadata or list default one
+func (l *ServicesList) maxConcurrency(srv service.IService) int {
+	if v, ok := srv.Meta()[MaxConcurrencyMetaKey]; ok {
+		if limit, err := strconv.Atoi(v); err == nil {
+			return limit
+		}
+	}
+	return l.MaxConcurrency
+}
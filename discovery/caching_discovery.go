@@ -0,0 +1,304 @@
+package discovery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// DefaultMaxStaleness is maximum age of cached result
+// served on backend errors if it is not configured
+const DefaultMaxStaleness = 15 * time.Minute
+
+// CachingDiscoveryOpts is options that needs
+// to configure CachingDiscovery instance
+type CachingDiscoveryOpts struct {
+	CacheDir     string        // directory to persist last known good results, in-memory only if empty
+	MaxStaleness time.Duration // maximum age of cached result served on backend errors (DefaultMaxStaleness if 0)
+	Logger       *slog.Logger  // optional logger, slog.Default() is used if nil
+}
+
+// CachingDiscovery is IServiceDiscovery wrapper that keeps
+// last known good result of the backend discovery and serves
+// it while the backend is unavailable
+type CachingDiscovery struct {
+	backend IServiceDiscovery
+	opts    *CachingDiscoveryOpts
+	logger  *slog.Logger
+
+	mu      sync.RWMutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry is last known good
+// discovery result for a service
+type cacheEntry struct {
+	Services  []cachedService `json:"services"`
+	UpdatedAt time.Time       `json:"updated_at"`
+
+	stale bool
+}
+
+// cachedService is serializable
+// representation of discovered service
+type cachedService struct {
+	Address  string            `json:"address"`
+	NodeName string            `json:"node_name,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Meta     map[string]string `json:"meta,omitempty"`
+	Locality service.Locality  `json:"locality"`
+	Checks   []service.Check   `json:"checks,omitempty"`
+	Weight   int               `json:"weight,omitempty"`
+}
+
+// NewCachingDiscovery create new discovery that
+// caches results of the given backend discovery
+func NewCachingDiscovery(backend IServiceDiscovery, opts *CachingDiscoveryOpts) (*CachingDiscovery, error) {
+	if backend == nil {
+		return nil, ErrNilDiscoverySource
+	}
+
+	if opts == nil {
+		opts = &CachingDiscoveryOpts{}
+	}
+
+	if opts.MaxStaleness < 0 {
+		return nil, ErrNegativeMaxStaleness
+	}
+
+	opts = &CachingDiscoveryOpts{
+		CacheDir:     opts.CacheDir,
+		MaxStaleness: opts.MaxStaleness,
+		Logger:       opts.Logger,
+	}
+	if opts.MaxStaleness == 0 {
+		opts.MaxStaleness = DefaultMaxStaleness
+	}
+
+	if opts.CacheDir != "" {
+		if err := os.MkdirAll(opts.CacheDir, 0o755); err != nil {
+			return nil, fmt.Errorf("create discovery cache dir: %w", err)
+		}
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &CachingDiscovery{
+		backend: backend,
+		opts:    opts,
+		logger:  logger,
+		entries: make(map[string]*cacheEntry),
+	}, nil
+}
+
+// Discover services via backend discovery. If backend
+// fails, last known good result is returned as long as
+// it is not older than configured max staleness, the same
+// is done if backend can't find any passing service
+func (d *CachingDiscovery) Discover(name string) ([]service.IService, error) {
+	services, err := d.backend.Discover(name)
+	if err == nil {
+		if len(services) != 0 {
+			d.store(name, services)
+		} else {
+			d.setStale(name, false)
+		}
+		return services, nil
+	}
+
+	entry := d.load(name)
+	if entry == nil {
+		return nil, err
+	}
+
+	if time.Since(entry.UpdatedAt) > d.opts.MaxStaleness {
+		return nil, fmt.Errorf("cached %s services are too stale (updated at %s): %w", name, entry.UpdatedAt.Format(time.RFC3339), err)
+	}
+	d.setStale(name, true)
+
+	d.logger.Warn("serving cached services due to discovery error", "service", name, "updated_at", entry.UpdatedAt, "error", err)
+
+	return entry.restore(), nil
+}
+
+// IsStale return true if last Discover call
+// for given service was served from cache
+func (d *CachingDiscovery) IsStale(name string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	entry, ok := d.entries[name]
+	return ok && entry.stale
+}
+
+// LastUpdated return time of the last successful
+// backend discovery for given service
+func (d *CachingDiscovery) LastUpdated(name string) time.Time {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if entry, ok := d.entries[name]; ok {
+		return entry.UpdatedAt
+	}
+
+	return time.Time{}
+}
+
+// store save given services as last known
+// good result in memory and on disk
+func (d *CachingDiscovery) store(name string, services []service.IService) {
+	entry := &cacheEntry{UpdatedAt: time.Now()}
+
+	for _, srv := range services {
+		if srv == nil {
+			continue
+		}
+
+		cached := cachedService{
+			Address:  srv.Address(),
+			NodeName: srv.NodeName(),
+			Meta:     srv.Meta(),
+			Locality: srv.Locality(),
+			Checks:   srv.Checks(),
+			Weight:   srv.Weight(),
+		}
+
+		for t := range srv.Tags() {
+			cached.Tags = append(cached.Tags, t)
+		}
+
+		entry.Services = append(entry.Services, cached)
+	}
+
+	d.mu.Lock()
+	d.entries[name] = entry
+	d.mu.Unlock()
+
+	if d.opts.CacheDir == "" {
+		return
+	}
+
+	if err := d.persist(name, entry); err != nil {
+		d.logger.Warn("persist discovery cache", "service", name, "error", err)
+	}
+}
+
+// setStale mark cached entry of given service
+// as served from cache or not without updating it
+func (d *CachingDiscovery) setStale(name string, stale bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if entry, ok := d.entries[name]; ok {
+		entry.stale = stale
+	}
+}
+
+// load return last known good result for
+// given service from memory or from disk
+func (d *CachingDiscovery) load(name string) *cacheEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.entries[name]
+	if !ok && d.opts.CacheDir != "" {
+		var err error
+		if entry, err = d.read(name); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				d.logger.Warn("read discovery cache", "service", name, "error", err)
+			}
+			return nil
+		}
+		d.entries[name] = entry
+	}
+
+	return entry
+}
+
+// persist atomically write given
+// entry to the cache file
+func (d *CachingDiscovery) persist(name string, entry *cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal cache entry: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(d.opts.CacheDir, ".cache-*")
+	if err != nil {
+		return fmt.Errorf("create temp cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write temp cache file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp cache file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), d.cachePath(name))
+}
+
+// read cache entry for given
+// service from the cache file
+func (d *CachingDiscovery) read(name string) (*cacheEntry, error) {
+	data, err := os.ReadFile(d.cachePath(name))
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, fmt.Errorf("unmarshal cache entry: %w", err)
+	}
+
+	return entry, nil
+}
+
+// cachePath return cache file
+// path for given service
+func (d *CachingDiscovery) cachePath(name string) string {
+	return filepath.Join(d.opts.CacheDir, url.PathEscape(name)+".json")
+}
+
+// restore create new services
+// from cached entry
+func (e *cacheEntry) restore() []service.IService {
+	services := make([]service.IService, 0, len(e.Services))
+
+	for _, cached := range e.Services {
+		var tags map[string]struct{}
+		if len(cached.Tags) != 0 {
+			tags = make(map[string]struct{}, len(cached.Tags))
+			for _, t := range cached.Tags {
+				tags[t] = struct{}{}
+			}
+		}
+
+		services = append(services, service.NewServiceWithOpts(cached.Address, &service.ServiceOpts{
+			NodeName: cached.NodeName,
+			Tags:     tags,
+			Meta:     cached.Meta,
+			Locality: cached.Locality,
+			Checks:   cached.Checks,
+			Weight:   cached.Weight,
+		}))
+	}
+
+	return services
+}
+
+var ErrNegativeMaxStaleness = errors.New("max staleness is negative")
@@ -0,0 +1,93 @@
+package discovery
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+type switchableDiscovery struct {
+	down     bool
+	notFound bool
+	backend  IServiceDiscovery
+}
+
+func (d *switchableDiscovery) Discover(name string) ([]service.IService, error) {
+	if d.down {
+		return nil, errors.New("connection refused")
+	}
+	if d.notFound {
+		return failingDiscovery{}.Discover(name)
+	}
+	return d.backend.Discover(name)
+}
+
+func TestCachingDiscoveryServesLastKnownGood(t *testing.T) {
+	manual, _ := NewManualDiscovery(TransportHttp, nil, "a", "b")
+	backend := &switchableDiscovery{backend: manual}
+	dir := t.TempDir()
+
+	disc, err := NewCachingDiscovery(backend, &CachingDiscoveryOpts{CacheDir: dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := disc.Discover("test"); err != nil {
+		t.Fatalf("unexpected discover error: %s", err)
+	}
+	if disc.IsStale("test") {
+		t.Errorf("fresh result is reported as stale")
+	}
+
+	backend.down = true
+
+	// new instance must restore the result persisted on disk
+	restarted, _ := NewCachingDiscovery(backend, &CachingDiscoveryOpts{CacheDir: dir, MaxStaleness: time.Hour})
+
+	services, err := restarted.Discover("test")
+	if err != nil {
+		t.Fatalf("unexpected error with cached result: %s", err)
+	}
+	if len(services) != 2 {
+		t.Errorf("want 2 cached services, got %d", len(services))
+	}
+	if !restarted.IsStale("test") {
+		t.Errorf("cached result is not reported as stale")
+	}
+
+	tooStale, _ := NewCachingDiscovery(backend, &CachingDiscoveryOpts{CacheDir: dir, MaxStaleness: time.Nanosecond})
+	if _, err := tooStale.Discover("test"); err == nil {
+		t.Errorf("unexpected nil error for too stale cache")
+	}
+	if tooStale.IsStale("test") {
+		t.Errorf("too stale cache which is not served is reported as stale")
+	}
+}
+
+func TestCachingDiscoveryServesCacheOnNotFound(t *testing.T) {
+	manual, _ := NewManualDiscovery(TransportHttp, nil, "a", "b")
+	backend := &switchableDiscovery{backend: manual}
+
+	disc, _ := NewCachingDiscovery(backend, &CachingDiscoveryOpts{CacheDir: t.TempDir()})
+	if _, err := disc.Discover("test"); err != nil {
+		t.Fatalf("unexpected discover error: %s", err)
+	}
+
+	// backend finds no passing instances during short blip
+	backend.notFound = true
+
+	services, err := disc.Discover("test")
+	if err != nil {
+		t.Fatalf("unexpected error with cached result: %s", err)
+	}
+	if len(services) != 2 || !disc.IsStale("test") {
+		t.Errorf("want 2 stale cached services, got %d", len(services))
+	}
+
+	backend.notFound = false
+	if _, err := disc.Discover("test"); err != nil || disc.IsStale("test") {
+		t.Errorf("want fresh result after backend recovery, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// MergeStrategy represent available strategies
+// to merge services from several discovery sources
+type MergeStrategy int
+
+const (
+	// MergeUnion return services from all
+	// the sources together
+	MergeUnion MergeStrategy = iota
+
+	// MergePriority return services from the first
+	// source (in given order) with non-empty result
+	MergePriority
+
+	// MergeDedupByAddress return services from all
+	// the sources keeping only first service for every address
+	MergeDedupByAddress
+)
+
+// mergeStrategies is slice of MergeStrategy
+// string representations
+var mergeStrategies = [...]string{
+	MergeUnion:          "union",
+	MergePriority:       "priority",
+	MergeDedupByAddress: "dedup",
+}
+
+// String return MergeStrategy enum as a string
+func (s MergeStrategy) String() string {
+	return mergeStrategies[s]
+}
+
+// CompositeSource is named discovery
+// source of CompositeDiscovery
+type CompositeSource struct {
+	Name      string            // source name used in errors reporting
+	Discovery IServiceDiscovery // source discovery
+}
+
+// CompositeDiscovery is IServiceDiscovery implementation
+// that combines several discovery sources
+type CompositeDiscovery struct {
+	sources  []CompositeSource
+	strategy MergeStrategy
+
+	mu   sync.RWMutex
+	errs map[string]error
+}
+
+// NewCompositeDiscovery create new discovery that merge
+// services from given sources with given strategy
+func NewCompositeDiscovery(strategy MergeStrategy, sources ...CompositeSource) (*CompositeDiscovery, error) {
+	if len(sources) == 0 {
+		return nil, ErrNoDiscoverySources
+	}
+
+	if strategy < MergeUnion || strategy > MergeDedupByAddress {
+		return nil, ErrUnsupportedMergeStrategy{strategy: int(strategy)}
+	}
+
+	named := make([]CompositeSource, 0, len(sources))
+	for i, s := range sources {
+		if s.Discovery == nil {
+			return nil, fmt.Errorf("source %d: %w", i, ErrNilDiscoverySource)
+		}
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("source-%d", i)
+		}
+		named = append(named, s)
+	}
+
+	return &CompositeDiscovery{
+		sources:  named,
+		strategy: strategy,
+		errs:     make(map[string]error),
+	}, nil
+}
+
+// Discover services from all the sources and merge them
+// with configured strategy. Error is returned only if
+// every source is failed, otherwise per-source errors
+// are available via SourceErrors
+func (d *CompositeDiscovery) Discover(name string) ([]service.IService, error) {
+	errs := make(map[string]error)
+	results := make([][]service.IService, 0, len(d.sources))
+
+	for _, s := range d.sources {
+		services, err := s.Discovery.Discover(name)
+		if err != nil {
+			errs[s.Name] = err
+			continue
+		}
+
+		results = append(results, services)
+
+		if d.strategy == MergePriority && len(services) != 0 {
+			break
+		}
+	}
+
+	d.mu.Lock()
+	d.errs = errs
+	d.mu.Unlock()
+
+	if len(results) == 0 {
+		return nil, ErrAllSourcesFailed{errs: errs}
+	}
+
+	return d.merge(results), nil
+}
+
+// SourceErrors return a copy of per-source
+// errors happened during last Discover call
+func (d *CompositeDiscovery) SourceErrors() map[string]error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	errs := make(map[string]error, len(d.errs))
+	for k, v := range d.errs {
+		errs[k] = v
+	}
+
+	return errs
+}
+
+// merge given sources results
+// with configured strategy
+func (d *CompositeDiscovery) merge(results [][]service.IService) (services []service.IService) {
+	switch d.strategy {
+	case MergePriority:
+		// results contains only sources tried before first
+		// non-empty one, so the last result is the one we need
+		return results[len(results)-1]
+	case MergeDedupByAddress:
+		seen := make(map[string]struct{})
+		for _, result := range results {
+			for _, srv := range result {
+				if srv == nil {
+					continue
+				}
+				if _, ok := seen[srv.Address()]; ok {
+					continue
+				}
+				seen[srv.Address()] = struct{}{}
+				services = append(services, srv)
+			}
+		}
+		return services
+	default:
+		for _, result := range results {
+			services = append(services, result...)
+		}
+		return services
+	}
+}
+
+var (
+	ErrNoDiscoverySources = errors.New("no discovery sources provided")
+	ErrNilDiscoverySource = errors.New("discovery source is nil")
+)
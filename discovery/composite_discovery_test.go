@@ -0,0 +1,73 @@
+package discovery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+type failingDiscovery struct{}
+
+func (failingDiscovery) Discover(service string) ([]service.IService, error) {
+	return nil, ErrServiceNotFound{service}
+}
+
+func TestCompositeDiscoveryStrategies(t *testing.T) {
+	first, _ := NewManualDiscovery(TransportHttp, nil, "a", "b")
+	second, _ := NewManualDiscovery(TransportHttp, nil, "b", "c")
+	empty, _ := NewManualDiscovery(TransportHttp, nil)
+
+	cases := []struct {
+		strategy MergeStrategy
+		sources  []CompositeSource
+		want     int
+	}{
+		{MergeUnion, []CompositeSource{{Discovery: first}, {Discovery: second}}, 4},
+		{MergeDedupByAddress, []CompositeSource{{Discovery: first}, {Discovery: second}}, 3},
+		{MergePriority, []CompositeSource{{Discovery: empty}, {Discovery: second}, {Discovery: first}}, 2},
+	}
+
+	for _, c := range cases {
+		disc, err := NewCompositeDiscovery(c.strategy, c.sources...)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		services, err := disc.Discover("test")
+		if err != nil {
+			t.Fatalf("unexpected discover error: %s", err)
+		}
+
+		if len(services) != c.want {
+			t.Errorf("%s strategy: want %d services, got %d", c.strategy, c.want, len(services))
+		}
+	}
+}
+
+func TestCompositeDiscoverySourceErrors(t *testing.T) {
+	manual, _ := NewManualDiscovery(TransportHttp, nil, "a")
+
+	disc, _ := NewCompositeDiscovery(MergeUnion,
+		CompositeSource{Name: "down", Discovery: failingDiscovery{}},
+		CompositeSource{Name: "static", Discovery: manual},
+	)
+
+	services, err := disc.Discover("test")
+	if err != nil {
+		t.Fatalf("unexpected error with one healthy source: %s", err)
+	}
+	if len(services) != 1 {
+		t.Errorf("want 1 service, got %d", len(services))
+	}
+	if _, ok := disc.SourceErrors()["down"]; !ok {
+		t.Errorf("source error was not reported")
+	}
+
+	disc, _ = NewCompositeDiscovery(MergeUnion, CompositeSource{Discovery: failingDiscovery{}})
+
+	_, err = disc.Discover("test")
+	if !errors.As(err, &ErrServiceNotFound{}) {
+		t.Errorf("want wrapped ErrServiceNotFound, got %v", err)
+	}
+}
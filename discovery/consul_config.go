@@ -0,0 +1,160 @@
+package discovery
+
+import (
+	"os"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+// ConsulConfig is full Consul client configuration
+// used by ConsulDiscovery to reach the cluster
+type ConsulConfig struct {
+	Scheme     string        // URI scheme for the Consul server ("http" or "https")
+	Datacenter string        // datacenter to query, agent default if empty
+	Namespace  string        // namespace to query, default if empty
+	Partition  string        // admin partition to query, default if empty
+	WaitTime   time.Duration // limits how long a blocking query will wait
+
+	Token     string // ACL token used for every request
+	TokenFile string // path to the file with ACL token, read once on start
+
+	HttpAuth *ConsulHttpAuth // HTTP basic auth credentials
+	TLS      *ConsulTLS      // TLS configuration
+}
+
+// ConsulHttpAuth is HTTP basic auth
+// credentials for Consul client
+type ConsulHttpAuth struct {
+	Username string
+	Password string
+}
+
+// ConsulTLS is TLS configuration
+// for Consul client
+type ConsulTLS struct {
+	ServerName         string // server name used to verify the hostname on the returned certificates
+	CAFile             string // path to the CA certificate
+	CAPath             string // path to the directory of CA certificates
+	CertFile           string // path to the client certificate, requires KeyFile
+	KeyFile            string // path to the client private key, requires CertFile
+	InsecureSkipVerify bool   // disable TLS host verification
+}
+
+// Validate check given Consul configuration
+// is consistent and referenced files are reachable
+func (c *ConsulConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.Scheme != "" && c.Scheme != "http" && c.Scheme != "https" {
+		return ErrInvalidConsulConfig{field: "Scheme", reason: "must be http or https"}
+	}
+
+	if c.WaitTime < 0 {
+		return ErrInvalidConsulConfig{field: "WaitTime", reason: "must not be negative"}
+	}
+
+	if c.Token != "" && c.TokenFile != "" {
+		return ErrInvalidConsulConfig{field: "TokenFile", reason: "can't be used together with Token"}
+	}
+
+	if err := checkFileExists("TokenFile", c.TokenFile); err != nil {
+		return err
+	}
+
+	if c.HttpAuth != nil && c.HttpAuth.Username == "" {
+		return ErrInvalidConsulConfig{field: "HttpAuth.Username", reason: "is empty"}
+	}
+
+	if c.TLS == nil {
+		return nil
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return ErrInvalidConsulConfig{field: "TLS", reason: "CertFile and KeyFile must be set together"}
+	}
+
+	// files are checked in fixed order to report the same error every run
+	for _, file := range []struct{ field, path string }{
+		{"TLS.CAFile", c.TLS.CAFile},
+		{"TLS.CAPath", c.TLS.CAPath},
+		{"TLS.CertFile", c.TLS.CertFile},
+		{"TLS.KeyFile", c.TLS.KeyFile},
+	} {
+		if err := checkFileExists(file.field, file.path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply copy given configuration
+// to the Consul client config
+func (c *ConsulConfig) apply(config *consul.Config) {
+	if c == nil {
+		return
+	}
+
+	if c.Scheme != "" {
+		config.Scheme = c.Scheme
+	}
+
+	if c.Datacenter != "" {
+		config.Datacenter = c.Datacenter
+	}
+
+	if c.Namespace != "" {
+		config.Namespace = c.Namespace
+	}
+
+	if c.Partition != "" {
+		config.Partition = c.Partition
+	}
+
+	if c.WaitTime != 0 {
+		config.WaitTime = c.WaitTime
+	}
+
+	if c.Token != "" {
+		config.Token = c.Token
+	}
+
+	if c.TokenFile != "" {
+		config.TokenFile = c.TokenFile
+	}
+
+	if c.HttpAuth != nil {
+		config.HttpAuth = &consul.HttpBasicAuth{
+			Username: c.HttpAuth.Username,
+			Password: c.HttpAuth.Password,
+		}
+	}
+
+	if c.TLS != nil {
+		config.TLSConfig = consul.TLSConfig{
+			Address:            c.TLS.ServerName,
+			CAFile:             c.TLS.CAFile,
+			CAPath:             c.TLS.CAPath,
+			CertFile:           c.TLS.CertFile,
+			KeyFile:            c.TLS.KeyFile,
+			InsecureSkipVerify: c.TLS.InsecureSkipVerify,
+		}
+	}
+}
+
+// checkFileExists return an error if
+// given non-empty path is unreachable
+func checkFileExists(field, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return ErrInvalidConsulConfig{field: field, reason: err.Error()}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package discovery
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+)
+
+func TestConsulConfigValidate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, []byte("token"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	tests := []struct {
+		name   string
+		config *ConsulConfig
+		field  string // empty if config is valid
+	}{
+		{"nil", nil, ""},
+		{"empty", &ConsulConfig{}, ""},
+		{"full", &ConsulConfig{
+			Scheme:    "https",
+			WaitTime:  time.Second,
+			TokenFile: file,
+			HttpAuth:  &ConsulHttpAuth{Username: "user"},
+			TLS:       &ConsulTLS{CAFile: file, CertFile: file, KeyFile: file},
+		}, ""},
+		{"scheme", &ConsulConfig{Scheme: "ftp"}, "Scheme"},
+		{"wait time", &ConsulConfig{WaitTime: -time.Second}, "WaitTime"},
+		{"token and file", &ConsulConfig{Token: "token", TokenFile: file}, "TokenFile"},
+		{"missing token file", &ConsulConfig{TokenFile: missing}, "TokenFile"},
+		{"http auth", &ConsulConfig{HttpAuth: &ConsulHttpAuth{}}, "HttpAuth.Username"},
+		{"cert without key", &ConsulConfig{TLS: &ConsulTLS{CertFile: file}}, "TLS"},
+		{"first missing file", &ConsulConfig{TLS: &ConsulTLS{CAFile: missing, CAPath: missing, CertFile: missing, KeyFile: missing}}, "TLS.CAFile"},
+		{"missing key", &ConsulConfig{TLS: &ConsulTLS{CertFile: file, KeyFile: missing}}, "TLS.KeyFile"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+
+			var invalid ErrInvalidConsulConfig
+			if !errors.As(err, &invalid) {
+				t.Fatalf("want ErrInvalidConsulConfig, got %v", err)
+			}
+			if invalid.field != tt.field {
+				t.Errorf("want error for field %s, got %s", tt.field, invalid.field)
+			}
+		})
+	}
+}
+
+func TestConsulConfigApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *ConsulConfig
+		check  func(t *testing.T, c *consul.Config)
+	}{
+		{"nil keeps defaults", nil, func(t *testing.T, c *consul.Config) {
+			if c.Scheme != "http" || c.Token != "" {
+				t.Errorf("defaults are changed: %+v", c)
+			}
+		}},
+		{"fields", &ConsulConfig{
+			Scheme:     "https",
+			Datacenter: "dc",
+			Namespace:  "ns",
+			Partition:  "part",
+			WaitTime:   time.Second,
+			Token:      "token",
+		}, func(t *testing.T, c *consul.Config) {
+			if c.Scheme != "https" || c.Datacenter != "dc" || c.Namespace != "ns" ||
+				c.Partition != "part" || c.WaitTime != time.Second || c.Token != "token" {
+				t.Errorf("fields are not applied: %+v", c)
+			}
+		}},
+		{"auth and tls", &ConsulConfig{
+			HttpAuth: &ConsulHttpAuth{Username: "user", Password: "pass"},
+			TLS:      &ConsulTLS{ServerName: "consul", CAFile: "ca", InsecureSkipVerify: true},
+		}, func(t *testing.T, c *consul.Config) {
+			if c.HttpAuth == nil || c.HttpAuth.Username != "user" || c.HttpAuth.Password != "pass" {
+				t.Errorf("http auth is not applied: %+v", c.HttpAuth)
+			}
+			if c.TLSConfig.Address != "consul" || c.TLSConfig.CAFile != "ca" || !c.TLSConfig.InsecureSkipVerify {
+				t.Errorf("tls is not applied: %+v", c.TLSConfig)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &consul.Config{Scheme: "http"}
+			tt.config.apply(config)
+			tt.check(t, config)
+		})
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"fmt"
 	consul "github.com/hashicorp/consul/api"
 
-	"github.com/gateway-fm/scriptorium/logger"
-
 	"github.com/gateway-fm/service-pool/service"
 )
 
@@ -24,7 +22,21 @@ func NewConsulDiscovery(transport TransportProtocol, opts *DiscoveryOpts, addr .
 		return nil, ErrInvalidArgumentsLength{length: len(addr), driver: DriverConsul}
 	}
 
+	if opts == nil {
+		opts = NilDiscoveryOptions()
+	}
+	if opts.isOptional {
+		if opts.optionalPath == "" {
+			return nil, ErrEmptyOptionalPath
+		}
+	}
+
+	if err := opts.consul.Validate(); err != nil {
+		return nil, err
+	}
+
 	config := consul.DefaultConfig()
+	opts.consul.apply(config)
 
 	if addr[0] != "" {
 		config.Address = addr[0]
@@ -35,15 +47,6 @@ func NewConsulDiscovery(transport TransportProtocol, opts *DiscoveryOpts, addr .
 		return nil, fmt.Errorf("connect to consul discovery: %w", err)
 	}
 
-	if opts == nil {
-		opts = NilDiscoveryOptions()
-	}
-	if opts.isOptional {
-		if opts.optionalPath == "" {
-			return nil, ErrEmptyOptionalPath
-		}
-	}
-
 	consulDiscovery := &ConsulDiscovery{
 		client:    c,
 		transport: transport,
@@ -87,12 +90,72 @@ func (d *ConsulDiscovery) createServiceFromConsul(srv *consul.ServiceEntry) serv
 		addr = AddEndOrRemoveFirstSlashIfNeeded(addr) + AddEndOrRemoveFirstSlashIfNeeded(d.opts.optionalPath)
 	}
 
-	logger.Log().Debug(fmt.Sprintf("discovered new service: %s", addr))
+	var nodeName string
+	if srv.Node != nil {
+		nodeName = srv.Node.Node
+	}
+
+	d.opts.log().Debug("discovered new service", "address", addr, "node", nodeName)
 
 	tagsMap := make(map[string]struct{})
 	for _, t := range srv.Service.Tags {
 		tagsMap[t] = struct{}{}
 	}
 
-	return service.NewService(addr, srv.Service.ID, tagsMap)
+	checks := make([]service.Check, 0, len(srv.Checks))
+	for _, c := range srv.Checks {
+		checks = append(checks, service.Check{
+			Name:   c.Name,
+			Status: c.Status,
+			Output: c.Output,
+		})
+	}
+
+	return service.NewServiceWithOpts(addr, &service.ServiceOpts{
+		NodeName: nodeName,
+		Tags:     tagsMap,
+		Meta:     srv.Service.Meta,
+		Locality: localityFromConsul(srv),
+		Checks:   checks,
+		Weight:   srv.Service.Weights.Passing,
+	})
+}
+
+// localityFromConsul create service Locality from consul
+// node and service details, node may be missing
+func localityFromConsul(srv *consul.ServiceEntry) service.Locality {
+	var (
+		locality     service.Locality
+		nodeLocality *consul.Locality
+	)
+
+	if srv.Node != nil {
+		locality.Datacenter = srv.Node.Datacenter
+		locality.Node = srv.Node.Node
+		locality.NodeMeta = srv.Node.Meta
+		nodeLocality = srv.Node.Locality
+	}
+
+	// service locality overrides node one
+	for _, l := range []*consul.Locality{nodeLocality, srv.Service.Locality} {
+		if l == nil {
+			continue
+		}
+		if l.Region != "" {
+			locality.Region = l.Region
+		}
+		if l.Zone != "" {
+			locality.Zone = l.Zone
+		}
+	}
+
+	if locality.Zone == "" {
+		if zone, ok := srv.Service.Meta[service.ZoneMetaKey]; ok {
+			locality.Zone = zone
+		} else {
+			locality.Zone = locality.NodeMeta[service.ZoneMetaKey]
+		}
+	}
+
+	return locality
 }
@@ -0,0 +1,90 @@
+package discovery
+
+import (
+	"testing"
+
+	consul "github.com/hashicorp/consul/api"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+func TestConsulCreateService(t *testing.T) {
+	d := &ConsulDiscovery{transport: TransportHttp, opts: NilDiscoveryOptions()}
+
+	srv := d.createServiceFromConsul(&consul.ServiceEntry{
+		Node: &consul.Node{
+			Node:       "node-1",
+			Datacenter: "dc1",
+			Meta:       map[string]string{service.ZoneMetaKey: "node-zone"},
+			Locality:   &consul.Locality{Region: "eu", Zone: "eu-1a"},
+		},
+		Service: &consul.AgentService{
+			Address:  "10.0.0.1",
+			Port:     8545,
+			Tags:     []string{"archive"},
+			Meta:     map[string]string{"chain": "1"},
+			Locality: &consul.Locality{Zone: "eu-1b"},
+		},
+	})
+
+	if srv.NodeName() != "node-1" {
+		t.Errorf("want node name node-1, got %q", srv.NodeName())
+	}
+	if _, ok := srv.Tags()["archive"]; !ok || srv.Meta()["chain"] != "1" {
+		t.Errorf("tags and meta are not carried: %v, %v", srv.Tags(), srv.Meta())
+	}
+
+	want := service.Locality{
+		Region:     "eu",
+		Datacenter: "dc1",
+		Zone:       "eu-1b",
+		Node:       "node-1",
+	}
+	got := srv.Locality()
+	if got.Region != want.Region || got.Datacenter != want.Datacenter || got.Zone != want.Zone || got.Node != want.Node {
+		t.Errorf("want locality %+v, got %+v", want, got)
+	}
+}
+
+func TestConsulLocality(t *testing.T) {
+	tests := []struct {
+		name string
+		srv  *consul.ServiceEntry
+		zone string
+	}{
+		{"service meta zone", &consul.ServiceEntry{
+			Node:    &consul.Node{Meta: map[string]string{service.ZoneMetaKey: "node"}},
+			Service: &consul.AgentService{Meta: map[string]string{service.ZoneMetaKey: "service"}},
+		}, "service"},
+		{"node meta zone", &consul.ServiceEntry{
+			Node:    &consul.Node{Meta: map[string]string{service.ZoneMetaKey: "node"}},
+			Service: &consul.AgentService{},
+		}, "node"},
+		{"without node", &consul.ServiceEntry{
+			Service: &consul.AgentService{Locality: &consul.Locality{Zone: "service"}},
+		}, "service"},
+		{"without node and zone", &consul.ServiceEntry{
+			Service: &consul.AgentService{},
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if zone := localityFromConsul(tt.srv).Zone; zone != tt.zone {
+				t.Errorf("want zone %q, got %q", tt.zone, zone)
+			}
+		})
+	}
+}
+
+func TestConsulCreateServiceWithoutNode(t *testing.T) {
+	d := &ConsulDiscovery{transport: TransportHttp, opts: NilDiscoveryOptions()}
+
+	srv := d.createServiceFromConsul(&consul.ServiceEntry{
+		Service: &consul.AgentService{Address: "10.0.0.1", Port: 8545},
+	})
+
+	if srv.NodeName() != "" || srv.Locality().Node != "" {
+		t.Errorf("unexpected node of service without node: %q", srv.NodeName())
+	}
+}
@@ -2,6 +2,8 @@ package discovery
 
 import (
 	"errors"
+	"log/slog"
+
 	"github.com/gateway-fm/service-pool/service"
 )
 
@@ -11,9 +13,15 @@ type IServiceDiscovery interface {
 	// Discover service by given name
 	Discover(service string) ([]service.IService, error)
 }
+
+// DiscoveryOpts is options that needs
+// to configure discovery instance
 type DiscoveryOpts struct {
 	isOptional   bool
 	optionalPath string
+
+	consul *ConsulConfig
+	logger *slog.Logger
 }
 
 // Creator is discovery factory function
@@ -39,6 +47,29 @@ func NewDiscoveryOpts(isPathOptional bool, optionalPath string) *DiscoveryOpts {
 	}
 }
 
+// WithConsulConfig set full Consul client
+// configuration used by ConsulDiscovery
+func (o *DiscoveryOpts) WithConsulConfig(cfg *ConsulConfig) *DiscoveryOpts {
+	o.consul = cfg
+	return o
+}
+
+// WithLogger set logger used
+// by discovery instance
+func (o *DiscoveryOpts) WithLogger(logger *slog.Logger) *DiscoveryOpts {
+	o.logger = logger
+	return o
+}
+
+// log return discovery logger
+// or default one if it is not set
+func (o *DiscoveryOpts) log() *slog.Logger {
+	if o.logger == nil {
+		return slog.Default()
+	}
+	return o.logger
+}
+
 // NilDiscoveryOptions to prevent nil pointers if there are no options
 func NilDiscoveryOptions() *DiscoveryOpts {
 	return &DiscoveryOpts{}
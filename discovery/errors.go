@@ -1,6 +1,10 @@
 package discovery
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
 
 // ErrUnsupportedDriver is error when
 // discovery driver is unsupported
@@ -47,3 +51,62 @@ type ErrInvalidArgumentsLength struct {
 func (e ErrInvalidArgumentsLength) Error() string {
 	return fmt.Sprintf("%d is invalid argument lenght to create new %s discovery", e.length, e.driver.String())
 }
+
+// ErrInvalidConsulConfig is error when
+// given Consul configuration is invalid
+type ErrInvalidConsulConfig struct {
+	field  string
+	reason string
+}
+
+// Error is throw error as a string
+func (e ErrInvalidConsulConfig) Error() string {
+	return fmt.Sprintf("invalid consul config field %s: %s", e.field, e.reason)
+}
+
+// ErrUnsupportedMergeStrategy is error when
+// composite discovery merge strategy is unsupported
+type ErrUnsupportedMergeStrategy struct {
+	strategy int
+}
+
+// Error is throw error as a string
+func (e ErrUnsupportedMergeStrategy) Error() string {
+	return fmt.Sprintf("unsupported merge strategy %d", e.strategy)
+}
+
+// ErrAllSourcesFailed is error when every
+// source of CompositeDiscovery is failed
+type ErrAllSourcesFailed struct {
+	errs map[string]error
+}
+
+// Error is throw error as a string
+func (e ErrAllSourcesFailed) Error() string {
+	names := make([]string, 0, len(e.errs))
+	for name := range e.errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", name, e.errs[name]))
+	}
+
+	return fmt.Sprintf("all discovery sources failed: %s", strings.Join(msgs, "; "))
+}
+
+// Errors return per-source errors
+func (e ErrAllSourcesFailed) Errors() map[string]error {
+	return e.errs
+}
+
+// Unwrap return all per-source errors
+func (e ErrAllSourcesFailed) Unwrap() []error {
+	errs := make([]error, 0, len(e.errs))
+	for _, err := range e.errs {
+		errs = append(errs, err)
+	}
+	return errs
+}
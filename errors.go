@@ -0,0 +1,169 @@
+package pool
+
+import "fmt"
+
+// ErrServiceNotFound is error when service
+// with given ID is not found in the list
+type ErrServiceNotFound struct {
+	ID string
+}
+
+// Error is throw error as a string
+func (e ErrServiceNotFound) Error() string {
+	return fmt.Sprintf("service with id %s not found", e.ID)
+}
+
+// ErrPoolNotFound is error when pool
+// with given name is not registered
+type ErrPoolNotFound struct {
+	Name string
+}
+
+// Error is throw error as a string
+func (e ErrPoolNotFound) Error() string {
+	return fmt.Sprintf("pool %q not found", e.Name)
+}
+
+// ErrUnsupportedOverrideKind is error when
+// override kind is not supported
+type ErrUnsupportedOverrideKind struct {
+	Kind string
+}
+
+// Error is throw error as a string
+func (e ErrUnsupportedOverrideKind) Error() string {
+	return fmt.Sprintf("unsupported override kind %q", e.Kind)
+}
+
+// ErrNotReady is error when pool doesn't have
+// enough routable services before context is done
+type ErrNotReady struct {
+	Pool       string
+	MinHealthy int // required number of routable services
+	Routable   int // number of routable services
+	Jailed     int // number of jailed services
+	Known      int // number of all services in the list
+	Err        error
+}
+
+// Error is throw error as a string
+func (e ErrNotReady) Error() string {
+	return fmt.Sprintf("pool %q is not ready: %d of %d required services are routable (%d known, %d jailed): %s",
+		e.Pool, e.Routable, e.MinHealthy, e.Known, e.Jailed, e.Err)
+}
+
+// Unwrap return context error
+func (e ErrNotReady) Unwrap() error {
+	return e.Err
+}
+
+// ErrNoHealthyServices is error when pool has
+// no service that can take a connection
+type ErrNoHealthyServices struct {
+	Pool   string
+	Jailed int // number of jailed services
+	Known  int // number of all services in the list
+}
+
+// Error is throw error as a string
+func (e ErrNoHealthyServices) Error() string {
+	if e.Known == 0 {
+		return fmt.Sprintf("no healthy services in pool %q: no services are discovered", e.Pool)
+	}
+	if e.Jailed == e.Known {
+		return fmt.Sprintf("no healthy services in pool %q: all %d services are jailed", e.Pool, e.Known)
+	}
+	return fmt.Sprintf("no healthy services in pool %q: %d of %d services are jailed", e.Pool, e.Jailed, e.Known)
+}
+
+// ErrPoolClosed is error when
+// pool is already closed
+type ErrPoolClosed struct {
+	Pool string
+}
+
+// Error is throw error as a string
+func (e ErrPoolClosed) Error() string {
+	return fmt.Sprintf("pool %q is closed", e.Pool)
+}
+
+// ErrPoolNotStarted is error when pool is not
+// started and has no services discovered
+type ErrPoolNotStarted struct {
+	Pool string
+}
+
+// Error is throw error as a string
+func (e ErrPoolNotStarted) Error() string {
+	return fmt.Sprintf("pool %q is not started", e.Pool)
+}
+
+// ErrAttemptsExhausted is error when all the
+// attempts of the retry executor are failed
+type ErrAttemptsExhausted struct {
+	Pool     string
+	Attempts int   // number of made attempts
+	Err      error // error of the last attempt
+}
+
+// Error is throw error as a string
+func (e ErrAttemptsExhausted) Error() string {
+	return fmt.Sprintf("all %d attempts in pool %q are failed: %s", e.Attempts, e.Pool, e.Err)
+}
+
+// Unwrap return error of the last attempt
+func (e ErrAttemptsExhausted) Unwrap() error {
+	return e.Err
+}
+
+// ErrServerStatus is error when service
+// responded with server error status code
+type ErrServerStatus struct {
+	Address string
+	Code    int
+}
+
+// Error is throw error as a string
+func (e ErrServerStatus) Error() string {
+	return fmt.Sprintf("service %s responded with status %d", e.Address, e.Code)
+}
+
+// ErrServicesSaturated is error when all the healthy services
+// reached their concurrency limits or rate limits
+type ErrServicesSaturated struct {
+	Pool string
+}
+
+// Error is throw error as a string
+func (e ErrServicesSaturated) Error() string {
+	return fmt.Sprintf("all healthy services in pool %q are saturated", e.Pool)
+}
+
+// ErrQueueFull is error when all the services are
+// saturated and the wait queue is full
+type ErrQueueFull struct {
+	Pool string
+	Size int
+}
+
+// Error is throw error as a string
+func (e ErrQueueFull) Error() string {
+	return fmt.Sprintf("all healthy services in pool %q are saturated and wait queue of %d is full", e.Pool, e.Size)
+}
+
+// ErrQueueTimeout is error when no service slot
+// is released while waiting in the queue
+type ErrQueueTimeout struct {
+	Pool string
+	Err  error
+}
+
+// Error is throw error as a string
+func (e ErrQueueTimeout) Error() string {
+	return fmt.Sprintf("wait for saturated services in pool %q: %s", e.Pool, e.Err)
+}
+
+// Unwrap return context error
+func (e ErrQueueTimeout) Unwrap() error {
+	return e.Err
+}
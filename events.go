@@ -0,0 +1,152 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// EventType represent available
+// pool lifecycle event types
+type EventType int
+
+const (
+	// EventDiscovered is emitted when new service is added to the
+	// pool by discovery, event State is where it is added to
+	EventDiscovered EventType = iota
+
+	// EventRemoved is emitted when service
+	// is removed from the pool
+	EventRemoved
+
+	// EventUpdated is emitted when rediscovered service
+	// with changed attributes replaces the old one
+	EventUpdated
+
+	// EventJailed is emitted when
+	// service is moved to jail
+	EventJailed
+
+	// EventReleased is emitted when service
+	// is moved from jail to healthy
+	EventReleased
+
+	// EventHealthCheckFailed is emitted
+	// when service healthcheck is failed
+	EventHealthCheckFailed
+
+	// EventDiscoveryError is emitted
+	// when discovery round is failed
+	EventDiscoveryError
+
+	// EventPoolEmpty is emitted when the last
+	// healthy service leaves the pool
+	EventPoolEmpty
+
+	// EventRemovalDelayed is emitted when discovery round would
+	// remove more than allowed share of known services
+	EventRemovalDelayed
+)
+
+// eventTypes is slice of EventType
+// string representations
+var eventTypes = [...]string{
+	EventDiscovered:        "discovered",
+	EventRemoved:           "removed",
+	EventUpdated:           "updated",
+	EventJailed:            "jailed",
+	EventReleased:          "released",
+	EventHealthCheckFailed: "healthcheck_failed",
+	EventDiscoveryError:    "discovery_error",
+	EventPoolEmpty:         "pool_empty",
+	EventRemovalDelayed:    "removal_delayed",
+}
+
+// String return EventType enum as a string
+func (t EventType) String() string {
+	return eventTypes[t]
+}
+
+// Event is pool lifecycle event
+type Event struct {
+	Type    EventType        // event type
+	Pool    string           // pool name
+	Service service.IService // event service, nil for pool-wide events
+	Err     error            // event error if any
+	Time    time.Time        // event time
+	State   string           // StateHealthy or StateJailed for EventDiscovered
+}
+
+// EventCallback is called on
+// every pool lifecycle event
+type EventCallback func(e Event)
+
+// defaultEventsBufferSize is default size
+// of every subscriber events channel
+const defaultEventsBufferSize = 64
+
+// eventBus deliver events to several subscribers
+// without blocking, events are dropped for the
+// subscriber which channel buffer is full
+type eventBus struct {
+	mu     sync.RWMutex
+	subs   map[uint64]chan Event
+	nextID uint64
+	buffer int
+
+	dropped atomic.Uint64
+}
+
+// newEventBus create new eventBus with
+// given subscriber channel buffer size
+func newEventBus(buffer int) *eventBus {
+	if buffer <= 0 {
+		buffer = defaultEventsBufferSize
+	}
+
+	return &eventBus{
+		subs:   make(map[uint64]chan Event),
+		buffer: buffer,
+	}
+}
+
+// subscribe return new events channel
+// and function to cancel subscription
+func (b *eventBus) subscribe() (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+
+	ch := make(chan Event, b.buffer)
+	b.subs[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(b.subs, id)
+			close(ch)
+		})
+	}
+}
+
+// publish deliver given event to
+// every subscriber without blocking
+func (b *eventBus) publish(e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
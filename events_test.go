@@ -0,0 +1,103 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+func TestServicesPoolSubscribe(t *testing.T) {
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1, TryUpInterval: time.Hour}, "http://a")
+
+	events, cancel := pool.Subscribe()
+	defer cancel()
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	srv := pool.NextService()
+	pool.List().FromHealthyToJail(srv.ID())
+
+	want := []EventType{EventDiscovered, EventJailed, EventPoolEmpty}
+	for _, w := range want {
+		select {
+		case e := <-events:
+			if e.Type != w {
+				t.Errorf("want %s event, got %s", w, e.Type)
+			}
+			if e.Pool != "TestServicePool" {
+				t.Errorf("unexpected event pool name %q", e.Pool)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s event was not delivered", w)
+		}
+	}
+}
+
+func TestServicesPoolDiscoveredEvents(t *testing.T) {
+	pool, _ := newStaticPoolWithOpts(&ServicesPoolsOpts{
+		ListOpts: &ServicesListOpts{TryUpTries: 1, TryUpInterval: time.Hour},
+		MutationFnc: func(srv service.IService) (service.IService, error) {
+			if srv.Address() == "http://down" {
+				return &unhealthyService{IService: srv}, nil
+			}
+			return healthySrvMutationFunc(srv)
+		},
+	}, "http://up", "http://down")
+
+	events, cancel := pool.Subscribe()
+	defer cancel()
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	want := []struct {
+		typ     EventType
+		address string
+		state   string
+	}{
+		{EventDiscovered, "http://up", StateHealthy},
+		{EventDiscovered, "http://down", StateJailed},
+		{EventHealthCheckFailed, "http://down", ""},
+		{EventJailed, "http://down", ""},
+	}
+	for _, w := range want {
+		select {
+		case e := <-events:
+			if e.Type != w.typ || e.Service.Address() != w.address || e.State != w.state {
+				t.Errorf("want %s event of %s with state %q, got %s of %s with state %q",
+					w.typ, w.address, w.state, e.Type, e.Service.Address(), e.State)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s event was not delivered", w.typ)
+		}
+	}
+}
+
+func TestEventBusDropsOnFullBuffer(t *testing.T) {
+	bus := newEventBus(1)
+
+	slow, cancelSlow := bus.subscribe()
+	defer cancelSlow()
+
+	other, cancelOther := bus.subscribe()
+	cancelOther()
+
+	bus.publish(Event{Type: EventDiscovered})
+	bus.publish(Event{Type: EventRemoved})
+
+	if got := bus.dropped.Load(); got != 1 {
+		t.Errorf("want 1 dropped event, got %d", got)
+	}
+
+	if e := <-slow; e.Type != EventDiscovered {
+		t.Errorf("want first event delivered, got %s", e.Type)
+	}
+
+	if _, ok := <-other; ok {
+		t.Errorf("channel of cancelled subscription is not closed")
+	}
+}
@@ -0,0 +1,89 @@
+package grpcpool
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pool "github.com/gateway-fm/service-pool"
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// BalancerName is name of the gRPC balancer
+// selecting services by the pool
+const BalancerName = "servicepool"
+
+// errNoReadyServices is picker error when there are no selectable
+// services with ready connection, it is not a status error, so
+// wait-for-ready RPCs wait for the next picker and others fail
+// with Unavailable code
+var errNoReadyServices = errors.New("no healthy services with ready connection")
+
+func init() {
+	balancer.Register(base.NewBalancerBuilder(BalancerName, pickerBuilder{}, base.Config{}))
+}
+
+// pickerBuilder is base.PickerBuilder
+// building pool pickers
+type pickerBuilder struct{}
+
+// Build implements base.PickerBuilder
+func (pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	p := &picker{subConns: make(map[string]balancer.SubConn, len(info.ReadySCs))}
+	for sc, scInfo := range info.ReadySCs {
+		servicesPool, id := serviceFromAddress(scInfo.Address)
+		if servicesPool == nil {
+			continue
+		}
+
+		p.pool = servicesPool
+		p.subConns[id] = sc
+	}
+
+	if p.pool == nil {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	return p
+}
+
+// picker is balancer.Picker selecting next active service of
+// the pool with ready connection, jailed, drained and excluded
+// services are skipped by the pool itself. Picker is rebuilt by
+// the resolver updates on pool membership and jail changes
+type picker struct {
+	pool     pool.IServicesPool
+	subConns map[string]balancer.SubConn // ready connections by service ID
+}
+
+// Pick implements balancer.Picker
+func (p *picker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	srv := p.pool.List().NextFiltered(func(srv service.IService) bool {
+		_, ready := p.subConns[srv.ID()]
+		return !ready
+	})
+	if srv == nil {
+		return balancer.PickResult{}, errNoReadyServices
+	}
+
+	return balancer.PickResult{
+		SubConn: p.subConns[srv.ID()],
+		Done: func(info balancer.DoneInfo) {
+			switch {
+			case info.Err == nil:
+				p.pool.ReportResult(srv, nil)
+			// only transport failures are reported as the service
+			// health issues, other errors are not reported at all
+			case status.Code(info.Err) == codes.Unavailable:
+				p.pool.ReportResult(srv, info.Err)
+			}
+		},
+	}, nil
+}
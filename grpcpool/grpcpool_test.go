@@ -0,0 +1,164 @@
+package grpcpool
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	pool "github.com/gateway-fm/service-pool"
+	"github.com/gateway-fm/service-pool/discovery"
+	"github.com/gateway-fm/service-pool/service"
+)
+
+func startServer(t *testing.T, servingStatus healthpb.HealthCheckResponse_ServingStatus) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected listen error: %s", err)
+	}
+
+	hs := health.NewServer()
+	hs.SetServingStatus("", servingStatus)
+
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+func TestResolverAndBalancer(t *testing.T) {
+	serving := startServer(t, healthpb.HealthCheckResponse_SERVING)
+	notServing := startServer(t, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	disc, _ := discovery.NewManualDiscovery(discovery.TransportGrpc, nil, serving, notServing)
+
+	servicesPool := pool.NewServicesPool(&pool.ServicesPoolsOpts{
+		Name:      "test",
+		Discovery: disc,
+		ListOpts:  &pool.ServicesListOpts{TryUpTries: 1},
+		MutationFnc: func(srv service.IService) (service.IService, error) {
+			srv.(*service.BaseService).SetStatus(service.StatusHealthy)
+			return srv, nil
+		},
+	})
+	if err := servicesPool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	conn, err := grpc.NewClient(Scheme+":///test",
+		grpc.WithResolvers(NewBuilder(servicesPool)),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("unexpected dial error: %s", err)
+	}
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true))
+		if err != nil {
+			t.Fatalf("unexpected check error: %s", err)
+		}
+		return resp.GetStatus()
+	}
+
+	// wait until both connections are ready
+	seen := make(map[healthpb.HealthCheckResponse_ServingStatus]bool)
+	for i := 0; i < 50 && len(seen) < 2; i++ {
+		seen[check()] = true
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("want both servers to be used, got %v", seen)
+	}
+
+	servicesPool.List().FromHealthyToJail(service.NewService(discovery.TransportGrpc.FormatAddress(notServing), "", nil).ID())
+
+	for i := 0; i < 4; i++ {
+		if got := check(); got != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("jailed server was picked")
+		}
+	}
+
+	// with all the services jailed fail fast RPCs fail with Unavailable
+	// and wait-for-ready ones wait until the service is released
+	servingID := service.NewService(discovery.TransportGrpc.FormatAddress(serving), "", nil).ID()
+	servicesPool.List().FromHealthyToJail(servingID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("want Unavailable for fail fast RPC, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = servicesPool.List().Release(servingID)
+	}()
+
+	if got := check(); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("want released server to be picked, got %s", got)
+	}
+}
+
+func TestPickerDone(t *testing.T) {
+	disc, _ := discovery.NewManualDiscovery(discovery.TransportGrpc, nil, "127.0.0.1:1")
+
+	servicesPool := pool.NewServicesPool(&pool.ServicesPoolsOpts{
+		Name:      "test",
+		Discovery: disc,
+		ListOpts:  &pool.ServicesListOpts{TryUpTries: 1},
+		MutationFnc: func(srv service.IService) (service.IService, error) {
+			srv.(*service.BaseService).SetStatus(service.StatusHealthy)
+			return srv, nil
+		},
+	})
+	if err := servicesPool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	srv := servicesPool.NextService()
+	p := &picker{pool: servicesPool, subConns: map[string]balancer.SubConn{srv.ID(): nil}}
+
+	done := func(err error) int {
+		t.Helper()
+
+		res, pickErr := p.Pick(balancer.PickInfo{})
+		if pickErr != nil {
+			t.Fatalf("unexpected pick error: %s", pickErr)
+		}
+		res.Done(balancer.DoneInfo{Err: err})
+
+		stats, _ := servicesPool.List().Stats(srv.ID())
+		return stats.ResultFailures
+	}
+
+	if failures := done(status.Error(codes.Unavailable, "down")); failures != 1 {
+		t.Fatalf("want Unavailable to be reported as failure, got %d failures", failures)
+	}
+	if failures := done(status.Error(codes.DeadlineExceeded, "slow")); failures != 1 {
+		t.Errorf("want not transport error to keep failures streak, got %d failures", failures)
+	}
+	if failures := done(nil); failures != 0 {
+		t.Errorf("want success to reset failures streak, got %d failures", failures)
+	}
+}
@@ -0,0 +1,196 @@
+package grpcpool
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+
+	pool "github.com/gateway-fm/service-pool"
+)
+
+// Scheme is gRPC target scheme of the
+// pools, targets look like servicepool:///name
+const Scheme = "servicepool"
+
+// serviceConfig is gRPC service config
+// enabling pool balancer by default
+var serviceConfig = `{"loadBalancingConfig":[{"` + BalancerName + `":{}}]}`
+
+// Address attributes keys
+type (
+	poolKey    struct{}
+	serviceKey struct{}
+)
+
+// Builder is gRPC resolver.Builder resolving targets
+// to the services of the registered pools
+type Builder struct {
+	mu    sync.RWMutex
+	pools map[string]pool.IServicesPool
+}
+
+// NewBuilder create new Builder
+// resolving all given pools
+func NewBuilder(pools ...pool.IServicesPool) *Builder {
+	b := &Builder{pools: make(map[string]pool.IServicesPool)}
+	for _, p := range pools {
+		b.Register(p)
+	}
+	return b
+}
+
+// Register add given pool to the builder,
+// pool with the same name is replaced
+func (b *Builder) Register(p pool.IServicesPool) {
+	if p == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pools[p.Name()] = p
+}
+
+// Scheme implements resolver.Builder
+func (b *Builder) Scheme() string {
+	return Scheme
+}
+
+// Build implements resolver.Builder
+func (b *Builder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	name := target.Endpoint()
+
+	b.mu.RLock()
+	p, ok := b.pools[name]
+	b.mu.RUnlock()
+
+	if !ok {
+		return nil, pool.ErrPoolNotFound{Name: name}
+	}
+
+	events, cancel := p.Subscribe()
+
+	r := &poolResolver{
+		pool:   p,
+		cc:     cc,
+		events: events,
+		cancel: cancel,
+		update: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+
+	r.wg.Add(1)
+	go r.watch()
+
+	r.ResolveNow(resolver.ResolveNowOptions{})
+
+	return r, nil
+}
+
+// poolResolver is gRPC resolver.Resolver updating
+// client connection with the services of the pool
+type poolResolver struct {
+	pool pool.IServicesPool
+	cc   resolver.ClientConn
+
+	events <-chan pool.Event
+	cancel func()
+
+	update chan struct{}
+	done   chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+}
+
+// ResolveNow implements resolver.Resolver
+func (r *poolResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.update <- struct{}{}:
+	default:
+	}
+}
+
+// Close implements resolver.Resolver
+func (r *poolResolver) Close() {
+	r.once.Do(func() {
+		r.cancel()
+		close(r.done)
+	})
+	r.wg.Wait()
+}
+
+// watch update client connection state on every pool membership
+// and jail change until closed, every update rebuilds the picker
+func (r *poolResolver) watch() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case e, ok := <-r.events:
+			if !ok {
+				return
+			}
+			switch e.Type {
+			case pool.EventDiscovered, pool.EventRemoved, pool.EventUpdated,
+				pool.EventJailed, pool.EventReleased:
+				r.updateState()
+			}
+		case <-r.update:
+			r.updateState()
+		}
+	}
+}
+
+// updateState send all the known services
+// of the pool to the client connection
+func (r *poolResolver) updateState() {
+	list := r.pool.List()
+
+	services := list.Healthy()
+	for _, srv := range list.Jailed() {
+		services = append(services, srv)
+	}
+
+	addresses := make([]resolver.Address, 0, len(services))
+	for _, srv := range services {
+		addresses = append(addresses, resolver.Address{
+			Addr:               hostPort(srv.Address()),
+			BalancerAttributes: attributes.New(poolKey{}, r.pool).WithValue(serviceKey{}, srv.ID()),
+		})
+	}
+
+	if err := r.cc.UpdateState(resolver.State{
+		Addresses:     addresses,
+		ServiceConfig: r.cc.ParseServiceConfig(serviceConfig),
+	}); err != nil {
+		r.cc.ReportError(err)
+	}
+}
+
+// hostPort return host and port of the
+// service address without the scheme
+func hostPort(address string) string {
+	if !strings.Contains(address, "://") {
+		return address
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return address
+	}
+	return u.Host
+}
+
+// serviceFromAddress return pool and service
+// ID of the resolved address attributes
+func serviceFromAddress(addr resolver.Address) (pool.IServicesPool, string) {
+	p, _ := addr.BalancerAttributes.Value(poolKey{}).(pool.IServicesPool)
+	id, _ := addr.BalancerAttributes.Value(serviceKey{}).(string)
+	return p, id
+}
@@ -0,0 +1,201 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// HedgePolicy is options that needs
+// to configure hedged requests
+type HedgePolicy struct {
+	// Delay is time to wait for the call before sending hedged one to
+	// another service, it is also used while pool has not enough
+	// latency samples to calculate Percentile (default is 100ms)
+	Delay time.Duration
+	// Percentile (0..1) of the request latencies observed by the pool
+	// to use as the delay instead of fixed one (0 to disable)
+	Percentile float64
+	// MaxHedges is max number of hedged calls
+	// in addition to the first one (default is 1)
+	MaxHedges int
+	// MaxRate is max share (0..1] of calls which can be hedged
+	// to keep hedging from multiplying the load (default is 0.1)
+	MaxRate float64
+}
+
+// Hedging defaults
+const (
+	defaultMaxHedges    = 1
+	defaultHedgeMaxRate = 0.1
+	defaultHedgeDelay   = 100 * time.Millisecond
+	maxHedgeTokens      = 10 // max number of hedged calls made in burst
+)
+
+// Hedge call given function with next active service and if it
+// doesn't finish within policy delay send the same call to another
+// not tried service. The first success is used and all the other
+// calls are cancelled, failed call is hedged immediately. Hedged
+// calls are limited by policy max rate
+func (p *ServicesPool) Hedge(ctx context.Context, fn AttemptFunc, policy *HedgePolicy) (err error) {
+	if policy == nil {
+		policy = &HedgePolicy{}
+	}
+
+	maxHedges := policy.MaxHedges
+	if maxHedges <= 0 {
+		maxHedges = defaultMaxHedges
+	}
+
+	maxRate := policy.MaxRate
+	if maxRate <= 0 {
+		maxRate = defaultHedgeMaxRate
+	}
+	p.hedges.deposit(maxRate)
+
+	ctx, span := p.tracer.Start(ctx, "servicepool.Hedge",
+		trace.WithAttributes(attrPool.String(p.name)))
+
+	tried := make(map[string]struct{}, maxHedges+1)
+	defer func() {
+		span.SetAttributes(attribute.Int("servicepool.attempts", len(tried)))
+		endSpan(span, err)
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan error, maxHedges+1)
+	skip := func(srv service.IService) bool {
+		_, ok := tried[srv.ID()]
+		return ok
+	}
+
+	// only the call made while nothing is in flight waits in the queue,
+	// hedged ones are not made if services are saturated to keep
+	// collecting results of the calls which are in flight
+	launch := func(queue bool) error {
+		acquire := p.tryAcquireService
+		if queue {
+			acquire = p.acquireService
+		}
+
+		srv, release, err := acquire(ctx, skip)
+		if err != nil {
+			return err
+		}
+		tried[srv.ID()] = struct{}{}
+
+		go func() {
+			start := time.Now()
+			err := fn(ctx, srv)
+			release()
+			if err == nil {
+				p.latency.observe(time.Since(start))
+			}
+
+			// calls cancelled after the first success are not reported
+			if err == nil || ctx.Err() == nil {
+				p.ReportResult(srv, err)
+			}
+
+			results <- err
+		}()
+
+		return nil
+	}
+
+	if err := launch(true); err != nil {
+		return err
+	}
+
+	delay := p.hedgeDelay(policy)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var (
+		inflight = 1
+		hedges   = 0
+		lastErr  error
+	)
+
+	for inflight > 0 {
+		select {
+		case err := <-results:
+			inflight--
+			if err == nil {
+				return nil
+			}
+			lastErr = err
+
+			if hedges < maxHedges && p.hedges.withdraw() && launch(inflight == 0) == nil {
+				hedges++
+				inflight++
+			}
+		case <-timer.C:
+			if hedges < maxHedges && p.hedges.withdraw() && launch(false) == nil {
+				hedges++
+				inflight++
+				timer.Reset(delay)
+			}
+		case <-ctx.Done():
+			return p.attemptsExhausted(len(tried), contextError(ctx, lastErr))
+		}
+	}
+
+	return p.attemptsExhausted(len(tried), lastErr)
+}
+
+// hedgeDelay return delay before
+// hedged call by given policy
+func (p *ServicesPool) hedgeDelay(policy *HedgePolicy) time.Duration {
+	if policy.Percentile > 0 {
+		if d, ok := p.latency.percentile(policy.Percentile); ok {
+			return d
+		}
+	}
+	if policy.Delay <= 0 {
+		return defaultHedgeDelay
+	}
+	return policy.Delay
+}
+
+// hedgeBudget limits rate of the hedged calls, every
+// call deposits its max rate and hedged one takes 1
+type hedgeBudget struct {
+	mu     sync.Mutex
+	tokens float64
+}
+
+// newHedgeBudget create new
+// hedgeBudget with full balance
+func newHedgeBudget() *hedgeBudget {
+	return &hedgeBudget{tokens: maxHedgeTokens}
+}
+
+// deposit add given amount of tokens
+func (b *hedgeBudget) deposit(amount float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.tokens+amount, maxHedgeTokens)
+}
+
+// withdraw take token for hedged call
+// or return false if there is no one
+func (b *hedgeBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
@@ -0,0 +1,124 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+func TestServicesPoolHedge(t *testing.T) {
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1}, "http://a", "http://b")
+	_ = pool.DiscoverServices()
+
+	var calls, cancelled atomic.Int32
+	slowFirst := func(ctx context.Context, _ service.IService) error {
+		if calls.Add(1) > 1 {
+			return nil
+		}
+		<-ctx.Done()
+		cancelled.Add(1)
+		return ctx.Err()
+	}
+
+	if err := pool.Hedge(context.Background(), slowFirst, &HedgePolicy{Delay: 10 * time.Millisecond}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("want hedged call, got %d calls", calls.Load())
+	}
+
+	time.Sleep(10 * time.Millisecond) // wait until slow call is cancelled
+
+	if cancelled.Load() != 1 {
+		t.Errorf("slow call was not cancelled")
+	}
+
+	// exhaust hedging budget
+	for pool.hedges.withdraw() {
+	}
+
+	calls.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := pool.Hedge(ctx, slowFirst, &HedgePolicy{Delay: time.Millisecond, MaxRate: 0.01})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want deadline error, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("want no hedged calls above max rate, got %d calls", calls.Load())
+	}
+}
+
+func TestServicesPoolHedgeDefaults(t *testing.T) {
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1}, "http://a", "http://b")
+	_ = pool.DiscoverServices()
+
+	var calls atomic.Int32
+	fast := func(context.Context, service.IService) error {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+
+	if err := pool.Hedge(context.Background(), fast, nil); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("want no hedged calls within default delay, got %d calls", calls.Load())
+	}
+
+	// exhaust hedging budget
+	for pool.hedges.withdraw() {
+	}
+
+	calls.Store(0)
+	errFailed := errors.New("failed")
+	err := pool.Hedge(context.Background(), func(context.Context, service.IService) error {
+		calls.Add(1)
+		return errFailed
+	}, &HedgePolicy{MaxRate: 0.01})
+	if !errors.Is(err, errFailed) || calls.Load() != 1 {
+		t.Errorf("want failed call not to be hedged above max rate, got %d calls: %v", calls.Load(), err)
+	}
+}
+
+func TestServicesPoolHedgeSaturated(t *testing.T) {
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1, MaxConcurrency: 1, QueueSize: 1}, "http://a", "http://b")
+	_ = pool.DiscoverServices()
+
+	// the only service left for hedged call is saturated
+	held, release, err := pool.AcquireService(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected acquire error: %s", err)
+	}
+	defer release()
+
+	var calls atomic.Int32
+	slow := func(_ context.Context, srv service.IService) error {
+		calls.Add(1)
+		if srv.ID() == held.ID() {
+			t.Errorf("saturated service %s is called", srv.ID())
+		}
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := pool.Hedge(ctx, slow, &HedgePolicy{Delay: time.Millisecond}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("hedge is blocked by saturated service for %s", elapsed)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("want no hedged calls to saturated service, got %d calls", calls.Load())
+	}
+}
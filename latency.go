@@ -0,0 +1,59 @@
+package pool
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Latency window configuration
+const (
+	latencyWindowSize       = 1024 // number of the last request latencies kept by the pool
+	latencyWindowMinSamples = 20   // number of samples needed to calculate percentile
+)
+
+// latencyWindow keeps latencies of
+// the last successful requests
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// newLatencyWindow create new latencyWindow
+func newLatencyWindow() *latencyWindow {
+	return &latencyWindow{samples: make([]time.Duration, 0, latencyWindowSize)}
+}
+
+// observe save latency of the request
+func (w *latencyWindow) observe(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.samples) < latencyWindowSize {
+		w.samples = append(w.samples, d)
+		return
+	}
+
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencyWindowSize
+}
+
+// percentile return given percentile (0..1) of the saved
+// latencies or false if there are not enough samples
+func (w *latencyWindow) percentile(q float64) (time.Duration, bool) {
+	w.mu.Lock()
+	if len(w.samples) < latencyWindowMinSamples {
+		w.mu.Unlock()
+		return 0, false
+	}
+	sorted := slices.Clone(w.samples)
+	w.mu.Unlock()
+
+	slices.Sort(sorted)
+
+	i := int(q * float64(len(sorted)-1))
+	i = max(0, min(i, len(sorted)-1))
+
+	return sorted[i], true
+}
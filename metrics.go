@@ -0,0 +1,46 @@
+package pool
+
+import (
+	"time"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// MetricsCollector is interface that collects
+// services pool and services list metrics
+type MetricsCollector interface {
+	// SetServicesCount set number of healthy
+	// and jailed services of the pool
+	SetServicesCount(pool string, healthy, jailed int)
+
+	// ObserveDiscovery observe duration and
+	// result of the pool discovery round
+	ObserveDiscovery(pool string, duration time.Duration, err error)
+
+	// ObserveHealthCheck observe duration and
+	// result of the service healthcheck
+	ObserveHealthCheck(pool string, srv service.IService, duration time.Duration, err error)
+
+	// IncTryUp count attempt to try
+	// up service from jail
+	IncTryUp(pool string, srv service.IService)
+
+	// IncNextNil count NextService
+	// calls returned no service
+	IncNextNil(pool string)
+
+	// ForgetService drop all metrics of
+	// the service removed from the pool
+	ForgetService(pool string, srv service.IService)
+}
+
+// noopMetrics is MetricsCollector
+// implementation that collects nothing
+type noopMetrics struct{}
+
+func (noopMetrics) SetServicesCount(string, int, int)                                 {}
+func (noopMetrics) ObserveDiscovery(string, time.Duration, error)                     {}
+func (noopMetrics) ObserveHealthCheck(string, service.IService, time.Duration, error) {}
+func (noopMetrics) IncTryUp(string, service.IService)                                 {}
+func (noopMetrics) IncNextNil(string)                                                 {}
+func (noopMetrics) ForgetService(string, service.IService)                            {}
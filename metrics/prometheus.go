@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	pool "github.com/gateway-fm/service-pool"
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// PrometheusCollector is Prometheus implementation
+// of pool.MetricsCollector interface
+type PrometheusCollector struct {
+	healthy *prometheus.GaugeVec
+	jailed  *prometheus.GaugeVec
+
+	discoveryDuration *prometheus.HistogramVec
+	discoveryErrors   *prometheus.CounterVec
+
+	healthCheckDuration *prometheus.HistogramVec
+	healthCheckFailures *prometheus.CounterVec
+
+	tryUps  *prometheus.CounterVec
+	nextNil *prometheus.CounterVec
+}
+
+var _ pool.MetricsCollector = (*PrometheusCollector)(nil)
+
+// NewPrometheusCollector create new Prometheus metrics collector
+// with given namespace and register it with given registerer
+func NewPrometheusCollector(reg prometheus.Registerer, namespace string) (*PrometheusCollector, error) {
+	serviceLabels := []string{"pool", "address", "node"}
+
+	c := &PrometheusCollector{
+		healthy: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "service_pool",
+			Name:      "healthy_services",
+			Help:      "Number of healthy services in the pool.",
+		}, []string{"pool"}),
+		jailed: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "service_pool",
+			Name:      "jailed_services",
+			Help:      "Number of jailed services in the pool.",
+		}, []string{"pool"}),
+		discoveryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "service_pool",
+			Name:      "discovery_duration_seconds",
+			Help:      "Duration of the pool discovery rounds.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"pool"}),
+		discoveryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "service_pool",
+			Name:      "discovery_errors_total",
+			Help:      "Number of failed pool discovery rounds.",
+		}, []string{"pool"}),
+		healthCheckDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "service_pool",
+			Name:      "healthcheck_duration_seconds",
+			Help:      "Duration of the service healthchecks.",
+			Buckets:   prometheus.DefBuckets,
+		}, serviceLabels),
+		healthCheckFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "service_pool",
+			Name:      "healthcheck_failures_total",
+			Help:      "Number of failed service healthchecks.",
+		}, serviceLabels),
+		tryUps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "service_pool",
+			Name:      "try_up_attempts_total",
+			Help:      "Number of attempts to try up service from jail.",
+		}, serviceLabels),
+		nextNil: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "service_pool",
+			Name:      "next_service_nil_total",
+			Help:      "Number of NextService calls returned no service.",
+		}, []string{"pool"}),
+	}
+
+	collectors := []prometheus.Collector{
+		c.healthy,
+		c.jailed,
+		c.discoveryDuration,
+		c.discoveryErrors,
+		c.healthCheckDuration,
+		c.healthCheckFailures,
+		c.tryUps,
+		c.nextNil,
+	}
+
+	for i, collector := range collectors {
+		if err := reg.Register(collector); err != nil {
+			// already registered collectors are dropped to keep retry possible
+			for _, registered := range collectors[:i] {
+				reg.Unregister(registered)
+			}
+			return nil, fmt.Errorf("register service pool metrics: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// SetServicesCount set number of healthy
+// and jailed services of the pool
+func (c *PrometheusCollector) SetServicesCount(pool string, healthy, jailed int) {
+	c.healthy.WithLabelValues(pool).Set(float64(healthy))
+	c.jailed.WithLabelValues(pool).Set(float64(jailed))
+}
+
+// ObserveDiscovery observe duration and
+// result of the pool discovery round
+func (c *PrometheusCollector) ObserveDiscovery(pool string, duration time.Duration, err error) {
+	c.discoveryDuration.WithLabelValues(pool).Observe(duration.Seconds())
+
+	if err != nil {
+		c.discoveryErrors.WithLabelValues(pool).Inc()
+	}
+}
+
+// ObserveHealthCheck observe duration and
+// result of the service healthcheck
+func (c *PrometheusCollector) ObserveHealthCheck(pool string, srv service.IService, duration time.Duration, err error) {
+	c.healthCheckDuration.WithLabelValues(pool, srv.Address(), srv.NodeName()).Observe(duration.Seconds())
+
+	if err != nil {
+		c.healthCheckFailures.WithLabelValues(pool, srv.Address(), srv.NodeName()).Inc()
+	}
+}
+
+// IncTryUp count attempt to try
+// up service from jail
+func (c *PrometheusCollector) IncTryUp(pool string, srv service.IService) {
+	c.tryUps.WithLabelValues(pool, srv.Address(), srv.NodeName()).Inc()
+}
+
+// IncNextNil count NextService
+// calls returned no service
+func (c *PrometheusCollector) IncNextNil(pool string) {
+	c.nextNil.WithLabelValues(pool).Inc()
+}
+
+// ForgetService drop all metrics of
+// the service removed from the pool
+func (c *PrometheusCollector) ForgetService(pool string, srv service.IService) {
+	labels := prometheus.Labels{"pool": pool, "address": srv.Address(), "node": srv.NodeName()}
+
+	c.healthCheckDuration.Delete(labels)
+	c.healthCheckFailures.Delete(labels)
+	c.tryUps.Delete(labels)
+}
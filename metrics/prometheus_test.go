@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	pool "github.com/gateway-fm/service-pool"
+	"github.com/gateway-fm/service-pool/discovery"
+	"github.com/gateway-fm/service-pool/service"
+)
+
+func TestPrometheusCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	collector, err := NewPrometheusCollector(reg, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := NewPrometheusCollector(reg, "test"); err == nil {
+		t.Errorf("unexpected nil error on duplicate registration")
+	}
+
+	manualDisc, _ := discovery.NewManualDiscovery(discovery.TransportHttp, nil, "localhost", "127.0.0.1")
+
+	p := pool.NewServicesPool(&pool.ServicesPoolsOpts{
+		Name:      "TestServicePool",
+		Discovery: manualDisc,
+		MutationFnc: func(srv service.IService) (service.IService, error) {
+			return srv, nil
+		},
+		Metrics: collector,
+	})
+
+	if err := p.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	if got := testutil.ToFloat64(collector.healthy.WithLabelValues("TestServicePool")); got != 2 {
+		t.Errorf("want 2 healthy services, got %v", got)
+	}
+
+	// discovered services have UnHealthy status, so there is nothing to select
+	p.NextService()
+
+	if got := testutil.ToFloat64(collector.nextNil.WithLabelValues("TestServicePool")); got != 1 {
+		t.Errorf("want 1 nil NextService call, got %v", got)
+	}
+
+	if got := testutil.CollectAndCount(collector.healthCheckDuration); got != 2 {
+		t.Errorf("want healthcheck metrics for 2 services, got %d", got)
+	}
+}
+
+func TestPrometheusCollectorRegisterError(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	// conflicting metric makes the last collector registration fail
+	conflict := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "test",
+		Subsystem: "service_pool",
+		Name:      "next_service_nil_total",
+		Help:      "Number of NextService calls returned no service.",
+	}, []string{"pool"})
+	reg.MustRegister(conflict)
+
+	if _, err := NewPrometheusCollector(reg, "test"); err == nil {
+		t.Fatalf("unexpected nil error on conflicting registration")
+	}
+
+	reg.Unregister(conflict)
+
+	if _, err := NewPrometheusCollector(reg, "test"); err != nil {
+		t.Errorf("unexpected error on retry after failed registration: %s", err)
+	}
+}
@@ -0,0 +1,261 @@
+package pool
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// OverrideKind represent available
+// manual operator override kinds
+type OverrideKind int
+
+const (
+	// OverrideExclude keeps the service in the
+	// list, but it is never selected by Next
+	OverrideExclude OverrideKind = iota
+
+	// OverrideForceJail moves the service to jail and
+	// keeps it there, try up won't release it
+	OverrideForceJail
+
+	// OverridePin restricts Next selection to pinned
+	// services only, if there are no pinned healthy
+	// services Next returns nil
+	OverridePin
+)
+
+// overrideKinds is slice of OverrideKind
+// string representations
+var overrideKinds = [...]string{
+	OverrideExclude:   "exclude",
+	OverrideForceJail: "force_jail",
+	OverridePin:       "pin",
+}
+
+// String return OverrideKind enum as a string
+func (k OverrideKind) String() string {
+	return overrideKinds[k]
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (k OverrideKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (k *OverrideKind) UnmarshalText(text []byte) error {
+	for i, s := range overrideKinds {
+		if string(text) == s {
+			*k = OverrideKind(i)
+			return nil
+		}
+	}
+	return ErrUnsupportedOverrideKind{Kind: string(text)}
+}
+
+// Override is manual operator override of the services
+// selection, it is matched with services by ID or address
+// and survives rediscovery of the services
+type Override struct {
+	Kind      OverrideKind `json:"kind"`
+	Target    string       `json:"target"`               // service ID or address
+	ExpiresAt time.Time    `json:"expires_at,omitempty"` // zero if override never expires
+}
+
+// NewOverride create new Override with given
+// kind and target expiring after given ttl
+// (zero or negative ttl to never expire)
+func NewOverride(kind OverrideKind, target string, ttl time.Duration) Override {
+	o := Override{Kind: kind, Target: target}
+	if ttl > 0 {
+		o.ExpiresAt = time.Now().Add(ttl)
+	}
+	return o
+}
+
+// Matches check if override targets given service
+func (o Override) Matches(srv service.IService) bool {
+	return srv != nil && (o.Target == srv.ID() || o.Target == srv.Address())
+}
+
+// Expired check if override is expired
+func (o Override) Expired() bool {
+	return !o.ExpiresAt.IsZero() && !time.Now().Before(o.ExpiresAt)
+}
+
+// overrideKey is unique key of the override
+type overrideKey struct {
+	kind   OverrideKind
+	target string
+}
+
+// SetOverride add given override to the list or replace
+// the one with the same kind and target. Matching healthy
+// services are moved to jail by force jail override
+func (l *ServicesList) SetOverride(o Override) {
+	key := overrideKey{o.Kind, o.Target}
+
+	l.mu.Lock()
+	l.overrides[key] = o
+	l.goExpire(key, &o)
+	l.notifySlots()
+
+	var toJail []string
+	if o.Kind == OverrideForceJail {
+		for _, srv := range l.healthy {
+			if o.Matches(srv) {
+				toJail = append(toJail, srv.ID())
+			}
+		}
+	}
+	l.mu.Unlock()
+
+	l.logger.Info("override is set", "kind", o.Kind.String(), "target", o.Target, "expires_at", o.ExpiresAt)
+
+	for _, id := range toJail {
+		l.FromHealthyToJail(id)
+	}
+}
+
+// RemoveOverride remove override with given kind and target,
+// services released from force jail are tried to up again
+func (l *ServicesList) RemoveOverride(kind OverrideKind, target string) bool {
+	return l.removeOverride(overrideKey{kind, target}, nil)
+}
+
+// Overrides return all active overrides
+// sorted by kind and target
+func (l *ServicesList) Overrides() []Override {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.activeOverrides()
+}
+
+// removeOverride remove override with given key if it is equal to
+// expected one (any if nil) and start try up of the services which
+// are not force jailed anymore
+func (l *ServicesList) removeOverride(key overrideKey, expected *Override) bool {
+	l.mu.Lock()
+
+	o, ok := l.overrides[key]
+	if !ok || expected != nil && o != *expected {
+		l.mu.Unlock()
+		return false
+	}
+	delete(l.overrides, key)
+	l.goExpire(key, nil)
+	l.notifySlots()
+
+	var toTryUp []service.IService
+	if o.Kind == OverrideForceJail {
+		for _, srv := range l.jail {
+			if o.Matches(srv) && !l.isOverridden(OverrideForceJail, srv) {
+				toTryUp = append(toTryUp, srv)
+			}
+		}
+	}
+	l.mu.Unlock()
+
+	l.logger.Info("override is removed", "kind", o.Kind.String(), "target", o.Target)
+
+	for _, srv := range toTryUp {
+		l.goTryUp(srv)
+	}
+
+	return true
+}
+
+// goExpire stop expiration timer of the override with given key and
+// start the new one if given override expires. Timers are tracked as
+// background goroutines and are not started while list is closed,
+// must be called under the lock
+func (l *ServicesList) goExpire(key overrideKey, o *Override) {
+	l.stopMu.Lock()
+	defer l.stopMu.Unlock()
+
+	l.stopTimer(key)
+
+	if o == nil || o.ExpiresAt.IsZero() || l.stopped {
+		return
+	}
+
+	expected := *o
+	l.wg.Add(1)
+	l.timers[key] = time.AfterFunc(time.Until(o.ExpiresAt), func() {
+		defer l.wg.Done()
+		l.removeOverride(key, &expected)
+	})
+}
+
+// stopTimer stop expiration timer of the override
+// with given key, must be called under the stop lock
+func (l *ServicesList) stopTimer(key overrideKey) {
+	t, ok := l.timers[key]
+	if !ok {
+		return
+	}
+
+	// timer which is already fired is done by itself
+	if t.Stop() {
+		l.wg.Done()
+	}
+	delete(l.timers, key)
+}
+
+// activeOverrides return all not expired overrides sorted
+// by kind and target, must be called under the lock
+func (l *ServicesList) activeOverrides() []Override {
+	var overrides []Override
+	for _, o := range l.overrides {
+		if !o.Expired() {
+			overrides = append(overrides, o)
+		}
+	}
+
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].Kind != overrides[j].Kind {
+			return overrides[i].Kind < overrides[j].Kind
+		}
+		return overrides[i].Target < overrides[j].Target
+	})
+
+	return overrides
+}
+
+// isOverridden check if active override of given kind
+// matches given service, must be called under the lock
+func (l *ServicesList) isOverridden(kind OverrideKind, srv service.IService) bool {
+	for key, o := range l.overrides {
+		if key.kind == kind && !o.Expired() && o.Matches(srv) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPins check if there is any active pin
+// override, must be called under the lock
+func (l *ServicesList) hasPins() bool {
+	for key, o := range l.overrides {
+		if key.kind == OverridePin && !o.Expired() {
+			return true
+		}
+	}
+	return false
+}
+
+// isSelectable check if given service can be selected
+// by Next regarding drain mark and overrides, must be
+// called under the lock
+func (l *ServicesList) isSelectable(srv service.IService, pinned bool) bool {
+	if _, drained := l.draining[srv.ID()]; drained {
+		return false
+	}
+	if l.isOverridden(OverrideExclude, srv) {
+		return false
+	}
+	return !pinned || l.isOverridden(OverridePin, srv)
+}
@@ -0,0 +1,234 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// Response headers set by Proxy
+// when BackendHeaders is enabled
+const (
+	HeaderBackend   = "X-Service-Pool-Backend"
+	HeaderBackendID = "X-Service-Pool-Backend-Id"
+	HeaderAttempts  = "X-Service-Pool-Attempts"
+)
+
+// ProxyOpts is options that needs
+// to configure Proxy instance
+type ProxyOpts struct {
+	Transport   http.RoundTripper // transport to make requests, http.DefaultTransport if nil
+	MaxAttempts int               // max number of attempts of idempotent requests on connection errors (default is 3)
+
+	// BackendHeaders enable response headers with address and ID
+	// of the service and number of attempts for debugging
+	BackendHeaders bool
+
+	// IsFailure check if response means service failure which is
+	// reported to the pool, by default 5xx status codes are failures
+	IsFailure func(resp *http.Response) bool
+
+	Logger *slog.Logger // optional logger, slog.Default() is used if nil
+}
+
+// Proxy is http.Handler forwarding incoming requests to the active
+// services of the pool. Idempotent requests failed with connection
+// errors are retried on another services if response is not started
+// and request has no body. WebSocket upgrades are proxied as well, ws
+// and wss service addresses are used as http and https ones
+type Proxy struct {
+	pool  IServicesPool
+	opts  ProxyOpts
+	proxy *httputil.ReverseProxy
+}
+
+// proxyAttempt is state of the single
+// attempt to proxy the request
+type proxyAttempt struct {
+	srv    service.IService
+	target *url.URL
+	number int
+	err    error
+}
+
+// proxyAttemptKey is context key of the proxyAttempt
+type proxyAttemptKey struct{}
+
+// NewProxy create new Proxy with given
+// services pool and configuration
+func NewProxy(pool IServicesPool, opts *ProxyOpts) *Proxy {
+	p := &Proxy{pool: pool}
+	if opts != nil {
+		p.opts = *opts
+	}
+
+	if p.opts.Transport == nil {
+		p.opts.Transport = http.DefaultTransport
+	}
+	if p.opts.MaxAttempts <= 0 {
+		p.opts.MaxAttempts = defaultMaxAttempts
+	}
+	if p.opts.IsFailure == nil {
+		p.opts.IsFailure = func(resp *http.Response) bool {
+			return resp.StatusCode >= http.StatusInternalServerError
+		}
+	}
+	if p.opts.Logger == nil {
+		p.opts.Logger = slog.Default()
+	}
+
+	p.proxy = &httputil.ReverseProxy{
+		Transport:      p.opts.Transport,
+		Rewrite:        p.rewrite,
+		ModifyResponse: p.modifyResponse,
+		ErrorHandler:   p.errorHandler,
+	}
+
+	return p
+}
+
+// ServeHTTP implements http.Handler
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	maxAttempts := 1
+	if isIdempotent(r) && (r.Body == nil || r.Body == http.NoBody) {
+		maxAttempts = p.opts.MaxAttempts
+	}
+
+	tw := &trackingWriter{ResponseWriter: w}
+	tried := make(map[string]struct{}, maxAttempts)
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		srv, release, err := p.pool.AcquireServiceFiltered(r.Context(), func(srv service.IService) bool {
+			_, ok := tried[srv.ID()]
+			return ok
+		})
+		if err != nil {
+			if lastErr != nil {
+				break
+			}
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		tried[srv.ID()] = struct{}{}
+
+		// invalid address is configuration error, so it is not reported as service failure
+		target, err := serviceURL(srv)
+		if err != nil {
+			release()
+			lastErr = err
+			p.opts.Logger.Warn("proxy service address is invalid", append(serviceLogAttrs(srv), "pool", p.pool.Name(), "error", err)...)
+			continue
+		}
+
+		a := &proxyAttempt{srv: srv, target: target, number: attempt}
+		p.proxy.ServeHTTP(tw, r.WithContext(context.WithValue(r.Context(), proxyAttemptKey{}, a)))
+		release()
+
+		if r.Context().Err() != nil {
+			return
+		}
+
+		p.pool.ReportResult(srv, a.err)
+		if a.err == nil || tw.wroteHeader {
+			return
+		}
+
+		lastErr = a.err
+		p.opts.Logger.Warn("proxy attempt is failed", append(serviceLogAttrs(srv), "pool", p.pool.Name(), "attempt", attempt, "error", a.err)...)
+	}
+
+	// error details with service addresses are not exposed to the client
+	p.opts.Logger.Warn("proxy attempts are exhausted", "pool", p.pool.Name(), "attempts", len(tried), "error", lastErr)
+
+	if p.opts.BackendHeaders {
+		w.Header().Set(HeaderAttempts, strconv.Itoa(len(tried)))
+	}
+	http.Error(w, fmt.Sprintf("all %d attempts in pool %q are failed", len(tried), p.pool.Name()), http.StatusBadGateway)
+}
+
+// rewrite direct outgoing request to the attempt service
+func (p *Proxy) rewrite(pr *httputil.ProxyRequest) {
+	a := pr.In.Context().Value(proxyAttemptKey{}).(*proxyAttempt)
+
+	pr.SetURL(a.target)
+	pr.SetXForwarded()
+}
+
+// modifyResponse detect failure response and
+// set backend headers if they are enabled
+func (p *Proxy) modifyResponse(resp *http.Response) error {
+	a := resp.Request.Context().Value(proxyAttemptKey{}).(*proxyAttempt)
+
+	if p.opts.IsFailure(resp) {
+		a.err = ErrServerStatus{Address: a.srv.Address(), Code: resp.StatusCode}
+	}
+
+	if p.opts.BackendHeaders {
+		resp.Header.Set(HeaderBackend, a.srv.Address())
+		resp.Header.Set(HeaderBackendID, a.srv.ID())
+		resp.Header.Set(HeaderAttempts, strconv.Itoa(a.number))
+	}
+
+	return nil
+}
+
+// errorHandler save proxy error to the attempt, response is
+// written by ServeHTTP after all the attempts are failed
+func (p *Proxy) errorHandler(_ http.ResponseWriter, r *http.Request, err error) {
+	a := r.Context().Value(proxyAttemptKey{}).(*proxyAttempt)
+	a.err = err
+}
+
+// serviceURL return URL of the given service
+// address with websocket schemes replaced
+func serviceURL(srv service.IService) (*url.URL, error) {
+	address := srv.Address()
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	target, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("parse service address %s: %w", srv.Address(), err)
+	}
+
+	switch target.Scheme {
+	case "ws":
+		target.Scheme = "http"
+	case "wss":
+		target.Scheme = "https"
+	}
+
+	return target, nil
+}
+
+// trackingWriter is http.ResponseWriter which
+// tracks if response header is written
+type trackingWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *trackingWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap return underlying http.ResponseWriter to keep
+// hijacking and flushing working with http.ResponseController
+func (w *trackingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
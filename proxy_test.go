@@ -0,0 +1,166 @@
+package pool
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+func TestProxy(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			_, _ = io.WriteString(w, r.URL.Path)
+			return
+		}
+
+		conn, rw, _ := http.NewResponseController(w).Hijack()
+		defer conn.Close()
+
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		_ = rw.Flush()
+
+		line, _ := rw.ReadString('\n')
+		_, _ = rw.WriteString(line)
+		_ = rw.Flush()
+	}))
+	defer backend.Close()
+
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1}, down.URL, strings.Replace(backend.URL, "http://", "ws://", 1))
+	_ = pool.DiscoverServices()
+
+	proxy := httptest.NewServer(NewProxy(pool, &ProxyOpts{BackendHeaders: true}))
+	defer proxy.Close()
+
+	for i := 0; i < 4; i++ {
+		resp, err := http.Get(proxy.URL + "/path")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || string(body) != "/path" {
+			t.Fatalf("request was not retried on healthy backend, got %d %q", resp.StatusCode, body)
+		}
+		if resp.Header.Get(HeaderBackend) == "" {
+			t.Errorf("backend header is not set")
+		}
+	}
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(proxy.URL, "http://"))
+	if err != nil {
+		t.Fatalf("unexpected dial error: %s", err)
+	}
+	defer conn.Close()
+
+	_, _ = io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: proxy\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("websocket upgrade is not proxied: %v", err)
+	}
+
+	_, _ = io.WriteString(conn, "hello\n")
+	if line, _ := reader.ReadString('\n'); line != "hello\n" {
+		t.Errorf("want echoed message, got %q", line)
+	}
+}
+
+func TestProxyErrors(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	backend := httptest.NewServer(http.NotFoundHandler())
+	defer backend.Close()
+
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1, MaxConcurrency: 1}, down.URL, backend.URL, "http://%zz")
+	_ = pool.DiscoverServices()
+
+	// backend is saturated, so after the other services
+	// fail the next attempt can't acquire any service
+	_, release, err := pool.AcquireServiceFiltered(context.Background(), func(srv service.IService) bool {
+		return srv.Address() != backend.URL
+	})
+	if err != nil {
+		t.Fatalf("unexpected acquire error: %s", err)
+	}
+	defer release()
+
+	proxy := httptest.NewServer(NewProxy(pool, &ProxyOpts{MaxAttempts: 3}))
+	defer proxy.Close()
+
+	resp, err := http.Get(proxy.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway || !strings.Contains(string(body), "attempts") {
+		t.Errorf("want 502 with exhausted attempts, got %d %q", resp.StatusCode, body)
+	}
+	if strings.Contains(string(body), strings.TrimPrefix(down.URL, "http://")) {
+		t.Errorf("service address is exposed in response %q", body)
+	}
+
+	// invalid address is not a failure of the service
+	invalid := service.NewService("http://%zz", "", nil)
+	if stats, _ := pool.List().Stats(invalid.ID()); stats.ResultFailures != 0 {
+		t.Errorf("invalid address is reported as service failure")
+	}
+
+	pool.Close()
+
+	resp, err = http.Get(proxy.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("want 503 from closed pool, got %d", resp.StatusCode)
+	}
+}
+
+func TestProxyNotIdempotent(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	backend := httptest.NewServer(http.NotFoundHandler())
+	defer backend.Close()
+
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1}, down.URL, backend.URL)
+	_ = pool.DiscoverServices()
+
+	proxy := httptest.NewServer(NewProxy(pool, nil))
+	defer proxy.Close()
+
+	// services are selected in turn, so one of the requests goes to the down service
+	failed := 0
+	for i := 0; i < 2; i++ {
+		resp, err := http.Post(proxy.URL, "text/plain", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode == http.StatusBadGateway {
+			failed++
+		}
+	}
+
+	if failed != 1 {
+		t.Errorf("want not idempotent request to be sent once, got %d failed of 2", failed)
+	}
+}
@@ -0,0 +1,186 @@
+package pool
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// Service metadata keys overriding rate limit of the service,
+// tags in key=value form (e.g. rate_limit=10) are used as well
+const (
+	RateLimitMetaKey = "rate_limit" // requests per second
+	RateBurstMetaKey = "rate_burst" // max number of requests at once
+)
+
+// RateLimit is token-bucket rate limit of the service
+type RateLimit struct {
+	Rate  float64 // requests per second (0 for no limit)
+	Burst int     // bucket size, Rate rounded up is used if 0
+}
+
+// RateLimitFunc return rate limit of given service
+type RateLimitFunc func(srv service.IService) RateLimit
+
+// burst return size of the bucket
+func (r RateLimit) burst() float64 {
+	if r.Burst > 0 {
+		return float64(r.Burst)
+	}
+	return max(1, math.Ceil(r.Rate))
+}
+
+// tokenBucket is token-bucket limiter of the single service
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// available return number of tokens in the bucket at given time
+func (b *tokenBucket) available(now time.Time) float64 {
+	return min(b.limit.burst(), b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate)
+}
+
+// take remove single token from the bucket
+func (b *tokenBucket) take(now time.Time) {
+	b.tokens = b.available(now) - 1
+	b.last = now
+}
+
+// wait return time until the bucket has a token
+func (b *tokenBucket) wait(now time.Time) time.Duration {
+	tokens := b.available(now)
+	if tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - tokens) / b.limit.Rate * float64(time.Second))
+}
+
+// RateRemaining return number of requests which can be made to the
+// service with given ID right now, false is returned if service is
+// not found or it has no rate limit
+func (l *ServicesList) RateRemaining(id string) (float64, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	srv, ok := l.jail[id]
+	if i := l.healthyIndex(id); i != -1 {
+		srv, ok = l.healthy[i], true
+	}
+	if !ok {
+		return 0, false
+	}
+
+	return l.rateRemaining(srv, time.Now())
+}
+
+// rateRemaining return number of tokens of given service without
+// changing its bucket, must be called under the read lock
+func (l *ServicesList) rateRemaining(srv service.IService, now time.Time) (float64, bool) {
+	limit := l.rateLimit(srv)
+	if limit.Rate <= 0 {
+		return 0, false
+	}
+
+	if b, ok := l.limiters[srv.ID()]; ok && b.limit == limit {
+		return b.available(now), true
+	}
+	return limit.burst(), true
+}
+
+// isRateLimited check if bucket of given service
+// is empty, must be called under the lock
+func (l *ServicesList) isRateLimited(srv service.IService, now time.Time) bool {
+	b := l.bucket(srv, now)
+	return b != nil && b.available(now) < 1
+}
+
+// takeToken take token from the bucket of given
+// service if it is limited, must be called under the lock
+func (l *ServicesList) takeToken(srv service.IService, now time.Time) {
+	if b := l.bucket(srv, now); b != nil {
+		b.take(now)
+	}
+}
+
+// rateWait return time until one of the rate limited healthy
+// services gets a token, must be called under the lock
+func (l *ServicesList) rateWait(now time.Time) time.Duration {
+	var wait time.Duration
+	for _, srv := range l.healthy {
+		if b := l.bucket(srv, now); b != nil {
+			if d := b.wait(now); d > 0 && (wait == 0 || d < wait) {
+				wait = d
+			}
+		}
+	}
+	return wait
+}
+
+// bucket return token bucket of given service creating or reconfiguring
+// it if needed, nil is returned if service has no rate limit, must be
+// called under the lock
+func (l *ServicesList) bucket(srv service.IService, now time.Time) *tokenBucket {
+	limit := l.rateLimit(srv)
+	if limit.Rate <= 0 {
+		delete(l.limiters, srv.ID())
+		return nil
+	}
+
+	b, ok := l.limiters[srv.ID()]
+	if ok && b.limit == limit {
+		return b
+	}
+
+	tokens := limit.burst()
+	if ok {
+		tokens = min(tokens, b.available(now))
+	}
+
+	b = &tokenBucket{limit: limit, tokens: tokens, last: now}
+	l.limiters[srv.ID()] = b
+
+	return b
+}
+
+// rateLimit return rate limit of given service from list function,
+// service metadata or tags, or list default one
+func (l *ServicesList) rateLimit(srv service.IService) RateLimit {
+	if l.RateLimitFunc != nil {
+		return l.RateLimitFunc(srv)
+	}
+
+	limit := l.RateLimit
+	if v, ok := serviceSetting(srv, RateLimitMetaKey); ok {
+		if rate, err := strconv.ParseFloat(v, 64); err == nil {
+			limit.Rate = rate
+		}
+	}
+	if v, ok := serviceSetting(srv, RateBurstMetaKey); ok {
+		if burst, err := strconv.Atoi(v); err == nil {
+			limit.Burst = burst
+		}
+	}
+
+	return limit
+}
+
+// serviceSetting return value of given key from service
+// metadata or from service tag in key=value form
+func serviceSetting(srv service.IService, key string) (string, bool) {
+	if v, ok := srv.Meta()[key]; ok {
+		return v, true
+	}
+
+	for tag := range srv.Tags() {
+		if v, ok := strings.CutPrefix(tag, key+"="); ok {
+			return v, true
+		}
+	}
+
+	return "", false
+}
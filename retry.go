@@ -0,0 +1,168 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// AttemptFunc is function making
+// single request to given service
+type AttemptFunc func(ctx context.Context, srv service.IService) error
+
+// RetryPolicy is options that needs
+// to configure retry executor
+type RetryPolicy struct {
+	MaxAttempts   int                             // max number of attempts including the first one (default is 3)
+	PerTryTimeout time.Duration                   // timeout of every single attempt (0 for no timeout)
+	Retryable     func(err error) bool            // check if error should be retried on another service (every error if nil)
+	Backoff       func(attempt int) time.Duration // delay before given retry attempt starting from 1 (no delay if nil)
+}
+
+// defaultMaxAttempts is default number
+// of attempts made by retry executor
+const defaultMaxAttempts = 3
+
+// ExponentialBackoff return backoff function doubling
+// base delay every next attempt up to max delay
+func ExponentialBackoff(base, max time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		return min(delay, max)
+	}
+}
+
+// Do call given function with next active service retrying it
+// on other services by given policy (default if nil). Every
+// attempt is made with service which is not tried yet and its
+// result is reported to the pool for passive health checking,
+// errors that are not retryable are not reported at all
+func (p *ServicesPool) Do(ctx context.Context, fn AttemptFunc, policy *RetryPolicy) (err error) {
+	if policy == nil {
+		policy = &RetryPolicy{}
+	}
+
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	ctx, span := p.tracer.Start(ctx, "servicepool.Do",
+		trace.WithAttributes(attrPool.String(p.name)))
+
+	tried := make(map[string]struct{}, maxAttempts)
+	defer func() {
+		span.SetAttributes(attribute.Int("servicepool.attempts", len(tried)))
+		endSpan(span, err)
+	}()
+
+	skip := func(srv service.IService) bool {
+		_, ok := tried[srv.ID()]
+		return ok
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 && policy.Backoff != nil {
+			Sleep(policy.Backoff(attempt), ctx.Done())
+			if ctx.Err() != nil {
+				return p.attemptsExhausted(len(tried), contextError(ctx, lastErr))
+			}
+		}
+
+		srv, release, err := p.acquireService(ctx, skip)
+		if err != nil {
+			if lastErr == nil {
+				return err
+			}
+			break
+		}
+		tried[srv.ID()] = struct{}{}
+
+		start := time.Now()
+		slot := &attemptSlot{release: release}
+		lastErr = p.attempt(context.WithValue(ctx, attemptSlotKey{}, slot), fn, srv, policy.PerTryTimeout)
+		if !slot.held {
+			release()
+		}
+		if lastErr == nil {
+			p.latency.observe(time.Since(start))
+			p.ReportResult(srv, nil)
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return p.attemptsExhausted(len(tried), contextError(ctx, lastErr))
+		}
+
+		// not retryable error is not caused by the service, so it is not reported
+		if policy.Retryable != nil && !policy.Retryable(lastErr) {
+			return lastErr
+		}
+
+		p.logger.Debug("attempt is failed", append(serviceLogAttrs(srv), "attempt", attempt, "error", lastErr)...)
+		p.ReportResult(srv, lastErr)
+	}
+
+	return p.attemptsExhausted(len(tried), lastErr)
+}
+
+// attempt call given function with given
+// service limiting it by given timeout
+func (p *ServicesPool) attempt(ctx context.Context, fn AttemptFunc, srv service.IService, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return fn(ctx, srv)
+}
+
+// attemptSlot is concurrency slot of the service of Do attempt
+// which can be held by the attempt function after it returns
+type attemptSlot struct {
+	release func()
+	held    bool
+}
+
+// attemptSlotKey is context key
+// of the attemptSlot
+type attemptSlotKey struct{}
+
+// holdSlot keep concurrency slot of the current Do attempt after
+// attempt function returns, returned function must be called to
+// release it. Nil is returned if context is not the attempt one
+func holdSlot(ctx context.Context) func() {
+	slot, ok := ctx.Value(attemptSlotKey{}).(*attemptSlot)
+	if !ok {
+		return nil
+	}
+
+	slot.held = true
+	return slot.release
+}
+
+// attemptsExhausted return ErrAttemptsExhausted
+// with given attempts number and last error
+func (p *ServicesPool) attemptsExhausted(attempts int, err error) error {
+	return ErrAttemptsExhausted{Pool: p.name, Attempts: attempts, Err: err}
+}
+
+// contextError return error of the done context
+// joined with given attempt error if it is not
+// caused by the context itself
+func contextError(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, ctx.Err()) {
+		return ctx.Err()
+	}
+	return errors.Join(ctx.Err(), err)
+}
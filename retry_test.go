@@ -0,0 +1,67 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+func TestServicesPoolDo(t *testing.T) {
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1}, "http://a", "http://b", "http://c")
+	_ = pool.DiscoverServices()
+
+	errFailed := errors.New("failed")
+
+	var tried []string
+	err := pool.Do(context.Background(), func(_ context.Context, srv service.IService) error {
+		tried = append(tried, srv.Address())
+		if len(tried) < 3 {
+			return errFailed
+		}
+		return nil
+	}, &RetryPolicy{Backoff: ExponentialBackoff(time.Millisecond, 5*time.Millisecond)})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(tried) != 3 || tried[0] == tried[1] || tried[1] == tried[2] || tried[0] == tried[2] {
+		t.Errorf("want 3 distinct services to be tried, got %v", tried)
+	}
+
+	stats, _ := pool.List().Stats(service.NewService(tried[0], "", nil).ID())
+	if stats.LastResultError != errFailed.Error() {
+		t.Errorf("failed attempt was not reported, got %+v", stats)
+	}
+
+	var exhausted ErrAttemptsExhausted
+	err = pool.Do(context.Background(), func(context.Context, service.IService) error {
+		return errFailed
+	}, &RetryPolicy{MaxAttempts: 5})
+	if !errors.As(err, &exhausted) || exhausted.Attempts != 3 || !errors.Is(err, errFailed) {
+		t.Errorf("want all 3 services to be tried, got %v", err)
+	}
+
+	streaks := make(map[string]int)
+	for _, srv := range pool.List().Healthy() {
+		stats, _ := pool.List().Stats(srv.ID())
+		streaks[srv.ID()] = stats.ResultFailures
+	}
+
+	attempts := 0
+	var failed service.IService
+	err = pool.Do(context.Background(), func(_ context.Context, srv service.IService) error {
+		attempts++
+		failed = srv
+		return errFailed
+	}, &RetryPolicy{Retryable: func(error) bool { return false }})
+	if !errors.Is(err, errFailed) || attempts != 1 {
+		t.Errorf("want single attempt for not retryable error, got %d: %v", attempts, err)
+	}
+
+	// not retryable error must not reset the failures streak
+	if stats, _ := pool.List().Stats(failed.ID()); stats.ResultFailures != streaks[failed.ID()] {
+		t.Errorf("want failures streak %d to be kept, got %d", streaks[failed.ID()], stats.ResultFailures)
+	}
+}
@@ -12,3 +12,37 @@ type ErrUnsupportedStatus struct {
 func (e ErrUnsupportedStatus) Error() string {
 	return fmt.Sprintf("unsupported service status %q", e.Status)
 }
+
+// ErrWsNotConnected is error when websocket
+// service has no established connection
+type ErrWsNotConnected struct {
+	Address string
+}
+
+// Error is throw error as a string
+func (e ErrWsNotConnected) Error() string {
+	return fmt.Sprintf("websocket service %s is not connected", e.Address)
+}
+
+// ErrWsServiceClosed is error when
+// websocket service is already closed
+type ErrWsServiceClosed struct {
+	Address string
+}
+
+// Error is throw error as a string
+func (e ErrWsServiceClosed) Error() string {
+	return fmt.Sprintf("websocket service %s is closed", e.Address)
+}
+
+// ErrPongTimeout is error when websocket service
+// doesn't answer ping with pong in time
+type ErrPongTimeout struct {
+	Address string
+	Timeout string
+}
+
+// Error is throw error as a string
+func (e ErrPongTimeout) Error() string {
+	return fmt.Sprintf("websocket service %s didn't answer ping in %s", e.Address, e.Timeout)
+}
@@ -0,0 +1,23 @@
+package service
+
+// ZoneMetaKey is metadata key used to get
+// service zone if discovery doesn't provide it
+const ZoneMetaKey = "zone"
+
+// Locality represent service placement
+// reported by discovery
+type Locality struct {
+	Region     string            // region of the node
+	Datacenter string            // datacenter of the node
+	Zone       string            // availability zone of the node
+	Node       string            // node name the service is running on
+	NodeMeta   map[string]string // node metadata from discovery
+}
+
+// Check represent single discovery
+// healthcheck result of the service
+type Check struct {
+	Name   string // check name
+	Status string // check status (passing, warning, critical)
+	Output string // check output
+}
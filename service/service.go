@@ -24,6 +24,19 @@ type IService interface {
 
 	Tags() map[string]struct{}
 
+	// Meta return service metadata from discovery
+	Meta() map[string]string
+
+	// Locality return service placement from discovery
+	Locality() Locality
+
+	// Checks return discovery healthchecks
+	// results of the service
+	Checks() []Check
+
+	// Weight return service weight from discovery
+	Weight() int
+
 	Close() error
 }
 
@@ -37,16 +50,48 @@ type BaseService struct {
 	address  string              // service address to connect
 	nodeName string              // node name from discovery
 	tags     map[string]struct{} // service tags
+	meta     map[string]string   // service metadata
+	locality Locality            // service placement
+	checks   []Check             // discovery healthchecks results
+	weight   int                 // service weight from discovery
+}
+
+// ServiceOpts is options that needs
+// to configure BaseService instance
+type ServiceOpts struct {
+	NodeName string              // node name from discovery
+	Tags     map[string]struct{} // service tags
+	Meta     map[string]string   // service metadata
+	Locality Locality            // service placement
+	Checks   []Check             // discovery healthchecks results
+	Weight   int                 // service weight from discovery
 }
 
 // NewService create new BaseService with address and discovery
 func NewService(address, nodeName string, tags map[string]struct{}) IService {
+	return NewServiceWithOpts(address, &ServiceOpts{
+		NodeName: nodeName,
+		Tags:     tags,
+	})
+}
+
+// NewServiceWithOpts create new BaseService with
+// address and all the discovery details
+func NewServiceWithOpts(address string, opts *ServiceOpts) IService {
+	if opts == nil {
+		opts = &ServiceOpts{}
+	}
+
 	return &BaseService{
 		id:       generateServiceID(address),
 		status:   StatusUnHealthy,
 		address:  address,
-		nodeName: nodeName,
-		tags:     tags,
+		nodeName: opts.NodeName,
+		tags:     opts.Tags,
+		meta:     opts.Meta,
+		locality: opts.Locality,
+		checks:   opts.Checks,
+		weight:   opts.Weight,
 	}
 }
 
@@ -85,6 +130,27 @@ func (n *BaseService) Tags() map[string]struct{} {
 	return n.tags
 }
 
+// Meta return service metadata from discovery
+func (n *BaseService) Meta() map[string]string {
+	return n.meta
+}
+
+// Locality return service placement from discovery
+func (n *BaseService) Locality() Locality {
+	return n.locality
+}
+
+// Checks return discovery healthchecks
+// results of the service
+func (n *BaseService) Checks() []Check {
+	return n.checks
+}
+
+// Weight return service weight from discovery
+func (n *BaseService) Weight() int {
+	return n.weight
+}
+
 func (n *BaseService) Close() error {
 	return nil
 }
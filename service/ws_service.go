@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Default websocket service configuration
+const (
+	defaultWsPingTimeout = 5 * time.Second
+	defaultWsMinBackoff  = 100 * time.Millisecond
+	defaultWsMaxBackoff  = 30 * time.Second
+)
+
+// WsServiceOpts is options that needs
+// to configure WsService instance
+type WsServiceOpts struct {
+	Dialer *websocket.Dialer // dialer to connect, websocket.DefaultDialer if nil
+	Header http.Header       // request header sent on connect
+
+	PingTimeout time.Duration // time to wait pong for healthcheck ping and to connect (default is 5s)
+	MinBackoff  time.Duration // delay before the first reconnect attempt (default is 100ms)
+	MaxBackoff  time.Duration // max delay between reconnect attempts (default is 30s)
+
+	// OnMessage is called with every data message received from
+	// the connection, it must not call Close of the service as
+	// Close waits for the connection reading to finish
+	OnMessage func(srv *WsService, messageType int, data []byte)
+	// OnConnect is called after every successful connect and
+	// reconnect, e.g. to restore subscriptions, it must not
+	// call Close of the service for the same reason
+	OnConnect func(srv *WsService)
+}
+
+// WsService is service keeping persistent websocket connection
+// which is checked by ping/pong and restored with backoff
+type WsService struct {
+	*BaseService
+
+	opts WsServiceOpts
+
+	mu           sync.Mutex
+	status       Status
+	conn         *websocket.Conn
+	reconnecting bool
+	closed       bool
+
+	writeMu  sync.Mutex
+	healthMu sync.Mutex
+	pongs    chan struct{}
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewWsService create new WsService with given base
+// service and configuration, connection is established
+// by the first HealthCheck
+func NewWsService(base *BaseService, opts *WsServiceOpts) *WsService {
+	s := &WsService{
+		BaseService: base,
+		status:      StatusUnHealthy,
+		pongs:       make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.Dialer == nil {
+		s.opts.Dialer = websocket.DefaultDialer
+	}
+	if s.opts.PingTimeout <= 0 {
+		s.opts.PingTimeout = defaultWsPingTimeout
+	}
+	if s.opts.MinBackoff <= 0 {
+		s.opts.MinBackoff = defaultWsMinBackoff
+	}
+	if s.opts.MaxBackoff <= 0 {
+		s.opts.MaxBackoff = defaultWsMaxBackoff
+	}
+
+	return s
+}
+
+// NewWsMutation return pool mutation function
+// wrapping discovered services to WsService
+func NewWsMutation(opts *WsServiceOpts) func(srv IService) (IService, error) {
+	return func(srv IService) (IService, error) {
+		base, ok := srv.(*BaseService)
+		if !ok {
+			return nil, fmt.Errorf("service %s is not BaseService", srv.Address())
+		}
+		return NewWsService(base, opts), nil
+	}
+}
+
+// HealthCheck check service health by sending ping
+// and waiting for pong, connection is established
+// if there is no one and it is not being restored
+func (s *WsService) HealthCheck() error {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	conn, err := s.connection()
+	if err != nil {
+		s.SetStatus(StatusUnHealthy)
+		return err
+	}
+
+	// drop pong of the previous timed out ping
+	select {
+	case <-s.pongs:
+	default:
+	}
+
+	if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.opts.PingTimeout)); err != nil {
+		s.SetStatus(StatusUnHealthy)
+		return fmt.Errorf("send ping to %s: %w", s.Address(), err)
+	}
+
+	timer := time.NewTimer(s.opts.PingTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-s.pongs:
+		s.SetStatus(StatusHealthy)
+		return nil
+	case <-timer.C:
+		s.SetStatus(StatusUnHealthy)
+		return ErrPongTimeout{Address: s.Address(), Timeout: s.opts.PingTimeout.String()}
+	case <-s.done:
+		return ErrWsServiceClosed{Address: s.Address()}
+	}
+}
+
+// Status return WsService current status
+func (s *WsService) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.status
+}
+
+// SetStatus set WsService current status
+func (s *WsService) SetStatus(status Status) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = status
+}
+
+// WriteMessage send message to the
+// service over current connection
+func (s *WsService) WriteMessage(messageType int, data []byte) error {
+	s.mu.Lock()
+	conn, closed := s.conn, s.closed
+	s.mu.Unlock()
+
+	if closed {
+		return ErrWsServiceClosed{Address: s.Address()}
+	}
+	if conn == nil {
+		return ErrWsNotConnected{Address: s.Address()}
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	return conn.WriteMessage(messageType, data)
+}
+
+// Close close the connection and
+// stop restoring it
+func (s *WsService) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.closed = true
+	s.status = StatusUnHealthy
+	close(s.done)
+
+	conn := s.conn
+	s.conn = nil
+	s.mu.Unlock()
+
+	var err error
+	if conn != nil {
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		err = conn.Close()
+	}
+
+	s.wg.Wait()
+
+	return err
+}
+
+// connection return current connection or
+// establish new one if it is not being restored
+func (s *WsService) connection() (*websocket.Conn, error) {
+	s.mu.Lock()
+	conn, reconnecting, closed := s.conn, s.reconnecting, s.closed
+	s.mu.Unlock()
+
+	switch {
+	case closed:
+		return nil, ErrWsServiceClosed{Address: s.Address()}
+	case conn != nil:
+		return conn, nil
+	case reconnecting:
+		return nil, ErrWsNotConnected{Address: s.Address()}
+	}
+
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+
+	return s.connection()
+}
+
+// connect dial the service and start reading messages from
+// new connection, dial is limited by ping timeout and it is
+// cancelled when service is closed
+func (s *WsService) connect() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.PingTimeout)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	conn, _, err := s.opts.Dialer.DialContext(ctx, s.Address(), s.opts.Header)
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", s.Address(), err)
+	}
+
+	conn.SetPongHandler(func(string) error {
+		select {
+		case s.pongs <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		_ = conn.Close()
+		return ErrWsServiceClosed{Address: s.Address()}
+	}
+	s.conn = conn
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go s.readLoop(conn)
+
+	if s.opts.OnConnect != nil {
+		s.opts.OnConnect(s)
+	}
+
+	return nil
+}
+
+// readLoop read messages from given connection
+// and start reconnect when it is broken
+func (s *WsService) readLoop(conn *websocket.Conn) {
+	defer s.wg.Done()
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		if s.opts.OnMessage != nil {
+			s.opts.OnMessage(s, messageType, data)
+		}
+	}
+
+	_ = conn.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.conn != conn {
+		return
+	}
+
+	s.conn = nil
+	s.status = StatusUnHealthy
+	s.reconnecting = true
+
+	s.wg.Add(1)
+	go s.reconnectLoop()
+}
+
+// reconnectLoop restore the connection with exponential
+// backoff until success or service is closed
+func (s *WsService) reconnectLoop() {
+	defer s.wg.Done()
+
+	backoff := s.opts.MinBackoff
+	for {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-s.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := s.connect(); err == nil {
+			s.mu.Lock()
+			s.reconnecting = false
+			s.mu.Unlock()
+			return
+		}
+
+		backoff = min(backoff*2, s.opts.MaxBackoff)
+	}
+}
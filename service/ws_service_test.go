@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWsService(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		conns []*websocket.Conn
+	)
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+
+		mu.Lock()
+		conns = append(conns, conn)
+		mu.Unlock()
+
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = conn.WriteMessage(messageType, data)
+		}
+	}))
+	defer server.Close()
+
+	connected := make(chan struct{}, 2)
+	messages := make(chan string, 1)
+
+	srv := NewWsService(NewService(strings.Replace(server.URL, "http://", "ws://", 1), "", nil).(*BaseService), &WsServiceOpts{
+		MinBackoff: 10 * time.Millisecond,
+		OnConnect:  func(*WsService) { connected <- struct{}{} },
+		OnMessage:  func(_ *WsService, _ int, data []byte) { messages <- string(data) },
+	})
+
+	if err := srv.HealthCheck(); err != nil || srv.Status() != StatusHealthy {
+		t.Fatalf("unexpected healthcheck error: %v", err)
+	}
+	<-connected
+
+	if err := srv.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatalf("unexpected write error: %s", err)
+	}
+	if msg := <-messages; msg != "hello" {
+		t.Errorf("want echoed message, got %q", msg)
+	}
+
+	mu.Lock()
+	_ = conns[0].Close()
+	mu.Unlock()
+
+	select {
+	case <-connected:
+	case <-time.After(time.Second):
+		t.Fatalf("service is not reconnected")
+	}
+
+	if err := srv.HealthCheck(); err != nil {
+		t.Errorf("unexpected healthcheck error after reconnect: %s", err)
+	}
+
+	if err := srv.Close(); err != nil {
+		t.Errorf("unexpected close error: %s", err)
+	}
+	if err := srv.HealthCheck(); !errors.As(err, &ErrWsServiceClosed{}) {
+		t.Errorf("want ErrWsServiceClosed, got %v", err)
+	}
+}
+
+func TestWsServiceConnectTimeout(t *testing.T) {
+	// server accepts connections but never answers the handshake
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected listen error: %s", err)
+	}
+	defer lis.Close()
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	srv := NewWsService(NewService("ws://"+lis.Addr().String(), "", nil).(*BaseService), &WsServiceOpts{
+		PingTimeout: 50 * time.Millisecond,
+	})
+	defer srv.Close()
+
+	start := time.Now()
+	if err := srv.HealthCheck(); err == nil {
+		t.Fatalf("unexpected nil error for not answering server")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("want connect to be limited by ping timeout, took %s", elapsed)
+	}
+}
+
+func TestWsMutationKeepsDiscoveryDetails(t *testing.T) {
+	base := NewServiceWithOpts("ws://127.0.0.1:1", &ServiceOpts{
+		Checks: []Check{{Name: "serfHealth", Status: "passing"}},
+		Weight: 5,
+	})
+
+	srv, err := NewWsMutation(nil)(base)
+	if err != nil {
+		t.Fatalf("unexpected mutation error: %s", err)
+	}
+	defer srv.Close()
+
+	if len(srv.Checks()) != 1 || srv.Weight() != 5 {
+		t.Errorf("mutated service lost discovery details: checks %v, weight %d", srv.Checks(), srv.Weight())
+	}
+}
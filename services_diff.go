@@ -0,0 +1,47 @@
+package pool
+
+import (
+	"maps"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// DiscoveryDiff is the set of changes between services
+// in the list and services found by discovery round
+type DiscoveryDiff struct {
+	Added   []service.IService // services to add to the list
+	Removed []service.IService // services to remove from the list
+	Updated []ServiceUpdate    // services to replace in the list
+}
+
+// ServiceUpdate is pair of the service in the list
+// and its rediscovered version with changed attributes
+type ServiceUpdate struct {
+	Old service.IService
+	New service.IService
+}
+
+// IsEmpty return true if diff has no changes
+func (d *DiscoveryDiff) IsEmpty() bool {
+	return d == nil || len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Updated) == 0
+}
+
+// serviceChanged return true if discovery attributes
+// (tags, metadata, locality or weight) of given
+// services with the same ID are different
+func serviceChanged(old, new service.IService) bool {
+	if !maps.Equal(old.Tags(), new.Tags()) || !maps.Equal(old.Meta(), new.Meta()) {
+		return true
+	}
+
+	oldLocality, newLocality := old.Locality(), new.Locality()
+	if oldLocality.Region != newLocality.Region ||
+		oldLocality.Datacenter != newLocality.Datacenter ||
+		oldLocality.Zone != newLocality.Zone ||
+		oldLocality.Node != newLocality.Node ||
+		!maps.Equal(oldLocality.NodeMeta, newLocality.NodeMeta) {
+		return true
+	}
+
+	return old.Weight() != new.Weight()
+}
@@ -1,12 +1,14 @@
 package pool
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/gateway-fm/scriptorium/logger"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/gateway-fm/service-pool/pkg/utils"
 	"github.com/gateway-fm/service-pool/service"
@@ -24,9 +26,52 @@ type IServicesList interface {
 	// to take a connection
 	Next() service.IService
 
+	// NextFiltered returns next healthy service to
+	// take a connection which is not skipped by
+	// given function
+	NextFiltered(skip func(srv service.IService) bool) service.IService
+
+	// NextFilteredE returns next healthy service to take a connection
+	// which is not skipped by given function, ErrServicesSaturated is
+	// returned if all of them are saturated or rate limited
+	NextFilteredE(skip func(srv service.IService) bool) (service.IService, error)
+
+	// Acquire returns next healthy service which is not saturated and
+	// takes its concurrency slot until release is called, if all the
+	// services are saturated it waits in the queue when it is enabled
+	Acquire(ctx context.Context, skip func(srv service.IService) bool) (service.IService, func(), error)
+
+	// TryAcquire returns next healthy service which is not saturated
+	// and takes its concurrency slot until release is called, it never
+	// waits in the queue and fails at once if all of them are saturated
+	TryAcquire(skip func(srv service.IService) bool) (service.IService, func(), error)
+
+	// InFlight return number of acquired
+	// slots of the service with given ID
+	InFlight(id string) int
+
+	// RateRemaining return number of requests which can be made to the
+	// service with given ID right now, false is returned if service is
+	// not found or it has no rate limit
+	RateRemaining(id string) (float64, bool)
+
+	// ReportResult report result of the request made to given
+	// service, service is jailed after configured number of
+	// failed requests in a row
+	ReportResult(srv service.IService, err error)
+
+	// ReportLatency report latency of the request made to given
+	// service, it is saved to the service stats for both
+	// successful and failed requests
+	ReportLatency(srv service.IService, d time.Duration)
+
 	// Add service to the list
 	Add(srv service.IService)
 
+	// ApplyDiff atomically apply all the changes of discovery round
+	// to the list, healthchecks are traced as children of given context
+	ApplyDiff(ctx context.Context, diff *DiscoveryDiff)
+
 	// IsServiceExists check is given service is
 	// already in list (healthy or jail)
 	IsServiceExists(srv service.IService) bool
@@ -58,9 +103,18 @@ type IServicesList interface {
 	// service from healthy slice by given srv index in that slice
 	RemoveFromHealthyByIndex(i int)
 
-	// Close Stop service list
+	// Close Stop service list, it is safe
+	// to call Close several times
 	Close()
 
+	// Open make closed service list ready to run
+	// again and restart try up of jailed services
+	Open()
+
+	// Shutdown Stop service list, wait for all its background
+	// goroutines and close and remove all the services
+	Shutdown(ctx context.Context) error
+
 	// Shuffle randomly shuffles list
 	Shuffle()
 
@@ -70,8 +124,49 @@ type IServicesList interface {
 	// Jailed returns a copy of jail map
 	Jailed() map[string]service.IService
 
+	// Snapshot atomically take point-in-time
+	// state of the list and all its services
+	Snapshot() ListSnapshot
+
+	// Stats return health statistics
+	// of the service with given ID
+	Stats(id string) (ServiceStats, bool)
+
+	// IsDraining check if service with given
+	// ID is drained and skipped by Next
+	IsDraining(id string) bool
+
+	// Jail manually move healthy service with given ID to
+	// jail and keep it there until it is released
+	Jail(id string) error
+
+	// Release manually move service with given ID from jail to
+	// healthy without healthcheck, undrain it and drop its
+	// force jail overrides
+	Release(id string) error
+
+	// Drain stop selecting service with given ID
+	// by Next until it is released, service is
+	// kept in the list and healthchecked
+	Drain(id string) error
+
+	// SetOverride add given override to the list or replace
+	// the one with the same kind and target
+	SetOverride(o Override)
+
+	// RemoveOverride remove override
+	// with given kind and target
+	RemoveOverride(kind OverrideKind, target string) bool
+
+	// Overrides return all active overrides
+	Overrides() []Override
+
 	SetOnSrvAddCallback(f ServiceCallbackE)
 
+	// SetOnEventCallback set callback called
+	// on every list lifecycle event
+	SetOnEventCallback(f EventCallback)
+
 	ModifyHealthy(modifier func(srv service.IService))
 }
 
@@ -86,6 +181,19 @@ type ServicesList struct {
 
 	jail map[string]service.IService
 
+	draining map[string]struct{}
+
+	overrides map[overrideKey]Override
+	timers    map[overrideKey]*time.Timer // guarded by stopMu
+
+	inflight  map[string]int
+	waiting   int
+	slotFreed chan struct{}
+
+	limiters map[string]*tokenBucket
+
+	stats *statsTracker
+
 	//muMain sync.Mutex
 	//muJail sync.Mutex
 
@@ -95,30 +203,118 @@ type ServicesList struct {
 	CheckInterval time.Duration
 	TryUpInterval time.Duration
 
-	Stop chan struct{}
+	PassiveFailureThreshold int
+	PassiveCooldown         time.Duration
+
+	MaxConcurrency int
+	QueueSize      int
+	QueueTimeout   time.Duration
+
+	RateLimit     RateLimit
+	RateLimitFunc RateLimitFunc
+
+	stop chan struct{} // closed on Close and recreated on Open, read via stopCh
+
+	stopMu  sync.Mutex
+	stopped bool
+	wg      sync.WaitGroup
 
 	onSrvAddCallback ServiceCallbackE
+
+	onEventCallback EventCallback
+
+	metrics MetricsCollector
+	tracer  trace.Tracer
+	logger  *slog.Logger
 }
 
+// defaultPassiveCooldown is default time service jailed
+// by failed requests stays in jail before try up
+const defaultPassiveCooldown = 10 * time.Second
+
 // ServicesListOpts is options that needs
 // to configure ServicesList instance
 type ServicesListOpts struct {
 	TryUpTries     int           // number of attempts to try up service from jail (0 for infinity tries)
 	TryUpInterval  time.Duration // interval for try up service from jail
 	ChecksInterval time.Duration // healthchecks interval
+
+	// PassiveFailureThreshold is number of failed requests in a row reported
+	// by ReportResult to move service to jail (0 to disable)
+	PassiveFailureThreshold int
+	// PassiveCooldown is time service jailed by failed requests stays in
+	// jail before try up, its healthcheck may pass while requests fail
+	// (TryUpInterval if 0 or defaultPassiveCooldown if both are 0)
+	PassiveCooldown time.Duration
+
+	// MaxConcurrency is default max number of acquired slots of every service,
+	// it is overridden by MaxConcurrencyMetaKey service metadata (0 for no limit)
+	MaxConcurrency int
+	// QueueSize is max number of Acquire calls waiting for a slot when
+	// all the services are saturated (0 to fail immediately)
+	QueueSize int
+	// QueueTimeout is max time to wait for a slot
+	// in the queue (0 to wait until context is done)
+	QueueTimeout time.Duration
+
+	// RateLimit is default rate limit of every service, it is overridden
+	// by RateLimitMetaKey and RateBurstMetaKey service metadata or tags
+	RateLimit RateLimit
+	// RateLimitFunc return rate limit of the service, it
+	// replaces RateLimit and service metadata if set
+	RateLimitFunc RateLimitFunc
+
+	Metrics        MetricsCollector     // optional metrics collector
+	TracerProvider trace.TracerProvider // optional OpenTelemetry tracer provider
+	Logger         *slog.Logger         // optional logger, slog.Default() is used if nil
 }
 
 // NewServicesList create new ServiceList instance
 // with given configuration
 func NewServicesList(serviceName string, opts *ServicesListOpts) IServicesList {
-	return &ServicesList{
+	if opts == nil {
+		opts = &ServicesListOpts{}
+	}
+
+	list := &ServicesList{
 		serviceName:   serviceName,
 		jail:          make(map[string]service.IService),
+		draining:      make(map[string]struct{}),
+		overrides:     make(map[overrideKey]Override),
+		timers:        make(map[overrideKey]*time.Timer),
+		inflight:      make(map[string]int),
+		slotFreed:     make(chan struct{}),
+		limiters:      make(map[string]*tokenBucket),
+		stats:         newStatsTracker(),
 		TryUpTries:    opts.TryUpTries,
 		CheckInterval: opts.ChecksInterval,
 		TryUpInterval: opts.TryUpInterval,
-		Stop:          make(chan struct{}),
+		stop:          make(chan struct{}),
+		metrics:       opts.Metrics,
+		tracer:        newTracer(opts.TracerProvider),
+
+		PassiveFailureThreshold: opts.PassiveFailureThreshold,
+		PassiveCooldown:         opts.PassiveCooldown,
+
+		MaxConcurrency: opts.MaxConcurrency,
+		QueueSize:      opts.QueueSize,
+		QueueTimeout:   opts.QueueTimeout,
+
+		RateLimit:     opts.RateLimit,
+		RateLimitFunc: opts.RateLimitFunc,
+	}
+
+	if list.metrics == nil {
+		list.metrics = noopMetrics{}
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
 	}
+	list.logger = logger.With("pool", serviceName)
+
+	return list
 }
 
 // Healthy return slice of all healthy services
@@ -149,56 +345,347 @@ func (l *ServicesList) Unhealthy() []service.IService {
 // Next returns next healthy service
 // to take a connection
 func (l *ServicesList) Next() service.IService {
+	return l.NextFiltered(nil)
+}
+
+// NextFiltered returns next healthy service to
+// take a connection which is not skipped by
+// given function
+func (l *ServicesList) NextFiltered(skip func(srv service.IService) bool) service.IService {
+	srv, _ := l.NextFilteredE(skip)
+	return srv
+}
+
+// NextFilteredE returns next healthy service to take a connection
+// which is not skipped by given function, ErrServicesSaturated is
+// returned if all of them are saturated or rate limited. Nil service
+// and error are returned if there are no healthy services
+func (l *ServicesList) NextFilteredE(skip func(srv service.IService) bool) (service.IService, error) {
 	defer l.mu.Unlock()
 	l.mu.Lock()
 
+	srv, saturated := l.next(skip)
+	if saturated {
+		return nil, ErrServicesSaturated{Pool: l.serviceName}
+	}
+	return srv, nil
+}
+
+// next returns next healthy service which is not skipped by given
+// function, saturated or rate limited and takes its token. If there
+// is no one it reports whether healthy services are skipped only by
+// their limits, must be called under the lock
+func (l *ServicesList) next(skip func(srv service.IService) bool) (service.IService, bool) {
 	if len(l.healthy) == 0 {
-		logger.Log().Info(fmt.Sprintf("list name %s no healthy services are present during list's Next() call", l.serviceName))
-		return nil
+		l.logger.Debug("no healthy services are present during list's Next() call")
+		return nil, false
 	}
 
+	pinned := l.hasPins()
+	now := time.Now()
+	saturated := false
+
 	next := l.nextIndex()
 	length := len(l.healthy) + next
 	for i := next; i < length; i++ {
 		idx := i % len(l.healthy)
-		if l.healthy[idx].Status() == service.StatusHealthy {
-			if i != next {
-				atomic.StoreUint64(&l.current, uint64(idx))
-			}
-			return l.healthy[idx]
+		srv := l.healthy[idx]
+		if !l.isSelectable(srv, pinned) || skip != nil && skip(srv) || srv.Status() != service.StatusHealthy {
+			continue
+		}
+		if l.isSaturated(srv) || l.isRateLimited(srv, now) {
+			saturated = true
+			continue
+		}
+
+		l.takeToken(srv, now)
+		if i != next {
+			atomic.StoreUint64(&l.current, uint64(idx))
 		}
+		return srv, false
 	}
 
-	logger.Log().Info(fmt.Sprintf("list name %s no healthy services are present after forloop during list's Next() call", l.serviceName))
-	return nil
+	l.logger.Debug("no healthy services are present after forloop during list's Next() call")
+	return nil, saturated
+}
+
+// ReportResult report result of the request made to given
+// service, service is jailed after configured number of
+// failed requests in a row
+func (l *ServicesList) ReportResult(srv service.IService, err error) {
+	if srv == nil {
+		return
+	}
+
+	failures := l.stats.observeResult(srv.ID(), err)
+	if l.PassiveFailureThreshold <= 0 || failures < l.PassiveFailureThreshold {
+		return
+	}
+
+	l.mu.RLock()
+	i := l.healthyIndex(srv.ID())
+	isHealthy := i != -1 && l.healthy[i] == srv
+	l.mu.RUnlock()
+
+	if !isHealthy {
+		return
+	}
+
+	l.logger.Warn("service is jailed due to failed requests", append(serviceLogAttrs(srv), "failures", failures, "error", err)...)
+
+	l.FromHealthyToJail(srv.ID())
+	l.goTryUpAfter(srv, l.passiveCooldown())
+}
+
+// ReportLatency report latency of the request made to given
+// service, it is saved to the service stats for both
+// successful and failed requests
+func (l *ServicesList) ReportLatency(srv service.IService, d time.Duration) {
+	if srv == nil {
+		return
+	}
+
+	l.stats.observeLatency(srv.ID(), d)
+}
+
+// passiveCooldown return time service jailed by
+// failed requests stays in jail before try up
+func (l *ServicesList) passiveCooldown() time.Duration {
+	switch {
+	case l.PassiveCooldown > 0:
+		return l.PassiveCooldown
+	case l.TryUpInterval > 0:
+		return l.TryUpInterval
+	default:
+		return defaultPassiveCooldown
+	}
 }
 
 // Add service to the list
 func (l *ServicesList) Add(srv service.IService) {
+	l.add(srv)
+}
+
+// add service to the list and return
+// true if it is added to healthy
+func (l *ServicesList) add(srv service.IService) bool {
 	if l.IsServiceExists(srv) {
-		logger.Log().Info(fmt.Sprintf("list name %s service already exists during Add, service with id %s with nodeName %s", l.serviceName, srv.ID(), srv.NodeName()))
-		return
+		l.logger.Info("service already exists during Add", serviceLogAttrs(srv)...)
+		return false
 	}
 
 	l.mu.Lock()
 
-	if err := srv.HealthCheck(); err != nil {
+	if l.isOverridden(OverrideForceJail, srv) {
 		l.jail[srv.ID()] = srv
-		logger.Log().Warn(fmt.Sprintf("list name %s service with id %s with nodeName %s can't be added to healthy due to healthcheck error: %s", l.serviceName, srv.ID(), srv.NodeName(), err.Error()))
+		l.stats.jailed(srv.ID())
+		l.logger.Info("service is force jailed by override", serviceLogAttrs(srv)...)
 
-		go l.TryUpService(srv, 0)
+		l.mu.Unlock()
+		l.reportCount()
+
+		l.emit(EventJailed, srv, nil)
+		return false
+	}
+
+	if err := l.healthCheck(context.Background(), srv); err != nil {
+		l.jail[srv.ID()] = srv
+		l.stats.jailed(srv.ID())
+		l.logger.Warn("service can't be added to healthy due to healthcheck error", append(serviceLogAttrs(srv), "error", err)...)
+
+		l.goTryUp(srv)
 
 		l.mu.Unlock()
-		return
+		l.reportCount()
+
+		l.emit(EventHealthCheckFailed, srv, err)
+		l.emit(EventJailed, srv, err)
+		return false
 	}
 
 	l.healthy = append(l.healthy, srv)
-	logger.Log().Info(fmt.Sprintf("list name %s service with id %s with nodeName %s with address %s added to list", l.serviceName, srv.ID(), srv.NodeName(), srv.Address()))
+	l.notifySlots()
+	l.logger.Info("service added to list", serviceLogAttrs(srv)...)
 	l.mu.Unlock()
+	l.reportCount()
 
 	if l.onSrvAddCallback != nil {
 		if err := l.onSrvAddCallback(srv); err != nil {
-			logger.Log().Warn(fmt.Sprintf("list name %s on service add callback error: %s", l.serviceName, err.Error()))
+			l.logger.Warn("on service add callback error", append(serviceLogAttrs(srv), "error", err)...)
+		}
+	}
+
+	return true
+}
+
+// ApplyDiff atomically apply all the changes of discovery
+// round to the list: every removed service is closed and
+// removed, every added one is added to healthy or to jail
+// and every updated one replaces its old version, healthchecks
+// are traced as children of given context
+func (l *ServicesList) ApplyDiff(ctx context.Context, diff *DiscoveryDiff) {
+	if diff.IsEmpty() {
+		return
+	}
+
+	// healthchecks are done before taking the lock
+	// to keep the list available during network calls
+	checkErrs := make(map[string]error, len(diff.Added)+len(diff.Updated))
+	passed := make(map[string]bool, len(diff.Added)+len(diff.Updated))
+	healthCheck := func(srv service.IService) {
+		err := l.healthCheck(ctx, srv)
+		if err != nil {
+			l.logger.Warn("service can't be added to healthy due to healthcheck error", append(serviceLogAttrs(srv), "error", err)...)
+			checkErrs[srv.ID()] = err
+		}
+		passed[srv.ID()] = err == nil
+	}
+
+	for _, srv := range diff.Added {
+		if srv != nil {
+			healthCheck(srv)
+		}
+	}
+	for _, update := range diff.Updated {
+		healthCheck(update.New)
+	}
+
+	removedIDs := make(map[string]struct{}, len(diff.Removed))
+	for _, srv := range diff.Removed {
+		removedIDs[srv.ID()] = struct{}{}
+	}
+
+	var removed, added, jailed, forced []service.IService
+	var discovered []Event
+
+	l.mu.Lock()
+
+	hadHealthy := len(l.healthy) != 0
+	healthy := make([]service.IService, 0, len(l.healthy)+len(diff.Added))
+	for _, srv := range l.healthy {
+		if _, ok := removedIDs[srv.ID()]; ok {
+			removed = append(removed, srv)
+			continue
+		}
+		healthy = append(healthy, srv)
+	}
+	l.healthy = healthy
+
+	for id := range removedIDs {
+		if srv, ok := l.jail[id]; ok {
+			removed = append(removed, srv)
+			delete(l.jail, id)
+		}
+		delete(l.draining, id)
+		delete(l.limiters, id)
+	}
+
+	for _, update := range diff.Updated {
+		id := update.New.ID()
+
+		// the old version is replaced in place if it is healthy
+		// and the new one passed the healthcheck
+		if i := l.healthyIndex(id); i != -1 {
+			removed = append(removed, l.healthy[i])
+			if passed[id] && !l.isOverridden(OverrideForceJail, update.New) {
+				l.healthy[i] = update.New
+				added = append(added, update.New)
+				continue
+			}
+			l.healthy = deleteFromSlice(l.healthy, i)
+		} else if srv, ok := l.jail[id]; ok {
+			removed = append(removed, srv)
+			delete(l.jail, id)
+		}
+
+		if l.isOverridden(OverrideForceJail, update.New) {
+			l.jail[id] = update.New
+			forced = append(forced, update.New)
+			continue
+		}
+
+		if !passed[id] {
+			l.jail[id] = update.New
+			jailed = append(jailed, update.New)
+			continue
+		}
+
+		l.healthy = append(l.healthy, update.New)
+		added = append(added, update.New)
+	}
+
+	for _, srv := range diff.Added {
+		if srv == nil || l.isServiceInJail(srv) || l.isServiceInHealthy(srv) {
+			continue
+		}
+
+		if l.isOverridden(OverrideForceJail, srv) {
+			l.jail[srv.ID()] = srv
+			forced = append(forced, srv)
+			discovered = append(discovered, Event{Type: EventDiscovered, Service: srv, State: StateJailed})
+			continue
+		}
+
+		if !passed[srv.ID()] {
+			l.jail[srv.ID()] = srv
+			jailed = append(jailed, srv)
+			discovered = append(discovered, Event{Type: EventDiscovered, Service: srv, State: StateJailed})
+			continue
+		}
+
+		l.healthy = append(l.healthy, srv)
+		added = append(added, srv)
+		discovered = append(discovered, Event{Type: EventDiscovered, Service: srv, State: StateHealthy})
+	}
+
+	becameEmpty := hadHealthy && len(l.healthy) == 0
+	l.notifySlots()
+
+	l.mu.Unlock()
+	l.reportCount()
+
+	if becameEmpty {
+		l.emit(EventPoolEmpty, nil, nil)
+	}
+
+	for _, srv := range removed {
+		l.logger.Info("service is removed from list", serviceLogAttrs(srv)...)
+
+		if err := srv.Close(); err != nil {
+			l.logger.Warn("unexpected error during service Close()", append(serviceLogAttrs(srv), "error", err)...)
+		}
+
+		if _, ok := removedIDs[srv.ID()]; ok {
+			l.metrics.ForgetService(l.serviceName, srv)
+			l.stats.forget(srv.ID())
+		}
+	}
+
+	// discovered services are reported before they are jailed
+	for _, e := range discovered {
+		e.Pool, e.Time = l.serviceName, time.Now()
+		l.emitEvent(e)
+	}
+
+	for _, srv := range jailed {
+		l.stats.jailed(srv.ID())
+		l.emit(EventHealthCheckFailed, srv, checkErrs[srv.ID()])
+		l.emit(EventJailed, srv, checkErrs[srv.ID()])
+		l.goTryUp(srv)
+	}
+
+	for _, srv := range forced {
+		l.stats.jailed(srv.ID())
+		l.emit(EventJailed, srv, nil)
+	}
+
+	for _, srv := range added {
+		l.logger.Info("service added to list", serviceLogAttrs(srv)...)
+
+		if l.onSrvAddCallback != nil {
+			if err := l.onSrvAddCallback(srv); err != nil {
+				l.logger.Warn("on service add callback error", append(serviceLogAttrs(srv), "error", err)...)
+			}
 		}
 	}
 }
@@ -223,22 +710,27 @@ func (l *ServicesList) IsServiceExists(srv service.IService) bool {
 // HealthChecks pings the healthy services
 // and update the status
 func (l *ServicesList) HealthChecks() {
+	ctx, span := l.tracer.Start(context.Background(), "servicepool.HealthChecks",
+		trace.WithAttributes(attrPool.String(l.serviceName)))
+	defer span.End()
+
 	for _, srv := range l.Healthy() {
 		if srv == nil {
-			logger.Log().Info(fmt.Sprintf("list name %s service is nil during hc loop, skipping the healthcheck for it", l.serviceName))
+			l.logger.Info("service is nil during hc loop, skipping the healthcheck for it")
 			continue
 		}
 
 		// TODO need to implement advanced logging level
 
-		if err := srv.HealthCheck(); err != nil {
-			logger.Log().Warn(fmt.Errorf("healthcheck error on list with name %s, service with id %s with nodeName %s: %w", l.serviceName, srv.ID(), srv.NodeName(), err).Error())
+		if err := l.healthCheck(ctx, srv); err != nil {
+			l.logger.Warn("healthcheck error", append(serviceLogAttrs(srv), "error", err)...)
+			l.emit(EventHealthCheckFailed, srv, err)
 
-			go func(service service.IService) {
-				l.FromHealthyToJail(service.ID())
-				logger.Log().Warn(fmt.Sprintf("%s service %s added to jail", l.serviceName, service.ID()))
-				l.TryUpService(service, 0)
-			}(srv)
+			l.goTrack(func() {
+				l.FromHealthyToJail(srv.ID())
+				l.logger.Warn("service added to jail", serviceLogAttrs(srv)...)
+				l.TryUpService(srv, 0)
+			})
 
 			continue
 		}
@@ -248,39 +740,66 @@ func (l *ServicesList) HealthChecks() {
 // HealthChecksLoop spawn healthchecks for
 // all healthy periodically
 func (l *ServicesList) HealthChecksLoop() {
-	logger.Log().Info("start healthchecks loop")
+	l.logger.Info("start healthchecks loop")
 
+	stop := l.stopCh()
 	for {
 		select {
-		case <-l.Stop:
-			logger.Log().Warn("stop healthchecks loop")
+		case <-stop:
+			l.logger.Warn("stop healthchecks loop")
 			return
 		default:
 			l.HealthChecks()
-			Sleep(l.CheckInterval, l.Stop)
+			Sleep(l.CheckInterval, stop)
 		}
 	}
 }
 
 // TryUpService recursively try to up service
 func (l *ServicesList) TryUpService(srv service.IService, try int) {
+	if l.isStopped() {
+		l.logger.Info("service list is closed, stop trying to up service", serviceLogAttrs(srv)...)
+		return
+	}
+
+	if !l.isJailed(srv) {
+		l.logger.Info("service is not in jail anymore, stop trying to up it", serviceLogAttrs(srv)...)
+		return
+	}
+
+	if l.isForceJailed(srv) {
+		l.logger.Info("service is force jailed by override, stop trying to up it", serviceLogAttrs(srv)...)
+		return
+	}
+
 	if l.TryUpTries != 0 && try >= l.TryUpTries {
-		logger.Log().Warn(fmt.Sprintf("list name %s maximum %d try to Up service with id %s with nodeName %s reached.... service will remove from service list", l.serviceName, l.TryUpTries, srv.ID(), srv.NodeName()))
+		l.logger.Warn("maximum tries to up service reached, service will be removed from list", append(serviceLogAttrs(srv), "tries", l.TryUpTries)...)
 		l.RemoveFromJail(srv)
+		l.emit(EventRemoved, srv, nil)
 		return
 	}
 
-	logger.Log().Info(fmt.Sprintf("list name %s %d try to up service with id %s with address %s with nodeName %s", l.serviceName, try, srv.ID(), srv.Address(), srv.NodeName()))
+	l.logger.Info("try to up service", append(serviceLogAttrs(srv), "try", try)...)
+	l.metrics.IncTryUp(l.serviceName, srv)
+	l.stats.tryUp(srv.ID(), try+1)
+
+	ctx, span := l.tracer.Start(context.Background(), "servicepool.TryUpService",
+		trace.WithAttributes(attrPool.String(l.serviceName), attrTry.Int(try)),
+		trace.WithAttributes(serviceAttributes(srv)...))
 
-	if err := srv.HealthCheck(); err != nil {
-		logger.Log().Warn(fmt.Errorf("list name %s service with id %s with nodeName %s healthcheck error: %w", l.serviceName, srv.ID(), srv.NodeName(), err).Error())
+	err := l.healthCheck(ctx, srv)
+	endSpan(span, err)
 
-		Sleep(l.TryUpInterval, l.Stop)
+	if err != nil {
+		l.logger.Warn("healthcheck error", append(serviceLogAttrs(srv), "error", err)...)
+		l.emit(EventHealthCheckFailed, srv, err)
+
+		Sleep(l.TryUpInterval, l.stopCh())
 		l.TryUpService(srv, try+1)
 		return
 	}
 
-	logger.Log().Info(fmt.Sprintf("list name %s service with id %s with nodeName %s is alive!", l.serviceName, srv.ID(), srv.NodeName()))
+	l.logger.Info("service is alive", serviceLogAttrs(srv)...)
 
 	l.FromJailToHealthy(srv)
 }
@@ -288,7 +807,6 @@ func (l *ServicesList) TryUpService(srv service.IService, try int) {
 // FromHealthyToJail move Unhealthy service
 // from Healthy slice to Jail map
 func (l *ServicesList) FromHealthyToJail(id string) {
-	defer l.mu.Unlock()
 	l.mu.Lock()
 
 	var (
@@ -305,60 +823,178 @@ func (l *ServicesList) FromHealthyToJail(id string) {
 	}
 
 	if index == -1 {
-		logger.Log().Warn(fmt.Sprintf("list name %s service with id %s is not found in healthy during FromHealthyToJail", l.serviceName, id))
+		l.mu.Unlock()
+		l.logger.Warn("service is not found in healthy during FromHealthyToJail", "service_id", id)
 		return
 	}
 
 	l.healthy = deleteFromSlice(l.healthy, index)
 	l.jail[srv.ID()] = srv
+	isEmpty := len(l.healthy) == 0
+
+	l.mu.Unlock()
+	l.reportCount()
+	l.stats.jailed(srv.ID())
 
-	logger.Log().Info(fmt.Sprintf("list name %s service with id %s is moved from healthy to jail", l.serviceName, id))
+	l.logger.Info("service is moved from healthy to jail", serviceLogAttrs(srv)...)
+
+	l.emit(EventJailed, srv, nil)
+	if isEmpty {
+		l.emit(EventPoolEmpty, nil, nil)
+	}
 }
 
 // FromJailToHealthy move Healthy service
 // from Jail map to Healthy slice
 func (l *ServicesList) FromJailToHealthy(srv service.IService) {
 	l.mu.Lock()
+	if !l.isInstanceInJail(srv) {
+		l.mu.Unlock()
+		l.logger.Warn("service is not found in jail during FromJailToHealthy", serviceLogAttrs(srv)...)
+		return
+	}
 	delete(l.jail, srv.ID())
 	l.mu.Unlock()
 
-	l.Add(srv)
+	if !l.add(srv) {
+		return
+	}
 
-	logger.Log().Info(fmt.Sprintf("list name %s service with id %s with nodeName %s is moved from jail to healthy", l.serviceName, srv.ID(), srv.NodeName()))
+	l.logger.Info("service is moved from jail to healthy", serviceLogAttrs(srv)...)
+
+	l.stats.released(srv.ID())
+	l.emit(EventReleased, srv, nil)
 }
 
 func (l *ServicesList) RemoveFromHealthyByIndex(i int) {
 	l.mu.Lock()
-	defer l.mu.Unlock()
 
 	srv := l.healthy[i]
-	logger.Log().Info(fmt.Sprintf("list name %s service with id %s with nodeName %s is about to be removed from healthy by index", l.serviceName, srv.ID(), srv.NodeName()))
+	l.logger.Info("service is about to be removed from healthy by index", serviceLogAttrs(srv)...)
 
 	if err := srv.Close(); err != nil {
-		logger.Log().Warn(fmt.Errorf("unexpected error during service Close(): %w", err).Error())
+		l.logger.Warn("unexpected error during service Close()", append(serviceLogAttrs(srv), "error", err)...)
 	}
 
 	l.healthy = deleteFromSlice(l.healthy, i)
+	delete(l.draining, srv.ID())
+	delete(l.limiters, srv.ID())
+	isEmpty := len(l.healthy) == 0
+
+	l.mu.Unlock()
+	l.reportCount()
+	l.metrics.ForgetService(l.serviceName, srv)
+	l.stats.forget(srv.ID())
+
+	if isEmpty {
+		l.emit(EventPoolEmpty, nil, nil)
+	}
 }
 
 // RemoveFromJail remove given
 // service from jail map
 func (l *ServicesList) RemoveFromJail(srv service.IService) {
-	defer l.mu.Unlock()
 	l.mu.Lock()
 
-	logger.Log().Info(fmt.Sprintf("list name %s service with id %s with nodeName %s is about to be removed from jail", l.serviceName, srv.ID(), srv.NodeName()))
+	l.logger.Info("service is about to be removed from jail", serviceLogAttrs(srv)...)
 
 	if err := srv.Close(); err != nil {
-		logger.Log().Warn(fmt.Errorf("unexpected error during service Close(): %w", err).Error())
+		l.logger.Warn("unexpected error during service Close()", append(serviceLogAttrs(srv), "error", err)...)
 	}
 
 	delete(l.jail, srv.ID())
+	delete(l.draining, srv.ID())
+	delete(l.limiters, srv.ID())
+
+	l.mu.Unlock()
+	l.reportCount()
+	l.metrics.ForgetService(l.serviceName, srv)
+	l.stats.forget(srv.ID())
 }
 
-// Close Stop service list handling
+// Close Stop service list handling, it is
+// safe to call Close several times
 func (l *ServicesList) Close() {
-	close(l.Stop)
+	l.stopMu.Lock()
+	defer l.stopMu.Unlock()
+
+	if l.stopped {
+		return
+	}
+
+	l.stopped = true
+	close(l.stop)
+
+	for key := range l.timers {
+		l.stopTimer(key)
+	}
+}
+
+// Open make closed service list ready to run
+// again and restart try up of jailed services
+func (l *ServicesList) Open() {
+	l.stopMu.Lock()
+	if !l.stopped {
+		l.stopMu.Unlock()
+		return
+	}
+
+	l.stopped = false
+	l.stop = make(chan struct{})
+	l.stopMu.Unlock()
+
+	// expiration timers are stopped on Close
+	l.mu.Lock()
+	for key, o := range l.overrides {
+		l.goExpire(key, &o)
+	}
+	l.mu.Unlock()
+
+	for _, srv := range l.Unhealthy() {
+		l.goTryUp(srv)
+	}
+}
+
+// Shutdown Stop service list, wait for all its background
+// goroutines and close and remove all the services. Services
+// are closed even if context is done before goroutines finish
+func (l *ServicesList) Shutdown(ctx context.Context) error {
+	l.Close()
+
+	done := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("wait for %s list goroutines: %w", l.serviceName, ctx.Err())
+	}
+
+	l.mu.Lock()
+	services := append([]service.IService{}, l.healthy...)
+	for _, srv := range l.jail {
+		services = append(services, srv)
+	}
+	l.healthy = nil
+	l.jail = make(map[string]service.IService)
+	l.draining = make(map[string]struct{})
+	l.limiters = make(map[string]*tokenBucket)
+	l.mu.Unlock()
+	l.reportCount()
+
+	for _, srv := range services {
+		if closeErr := srv.Close(); closeErr != nil {
+			l.logger.Warn("unexpected error during service Close()", append(serviceLogAttrs(srv), "error", closeErr)...)
+		}
+		l.metrics.ForgetService(l.serviceName, srv)
+		l.stats.forget(srv.ID())
+	}
+
+	return err
 }
 
 func (l *ServicesList) Shuffle() {
@@ -400,6 +1036,109 @@ func (l *ServicesList) Jailed() map[string]service.IService {
 	return jailed
 }
 
+// Stats return health statistics
+// of the service with given ID
+func (l *ServicesList) Stats(id string) (ServiceStats, bool) {
+	return l.stats.get(id)
+}
+
+// IsDraining check if service with given
+// ID is drained and skipped by Next
+func (l *ServicesList) IsDraining(id string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	_, ok := l.draining[id]
+	return ok
+}
+
+// Jail manually move healthy service with given ID to jail and
+// keep it there until it is released, it is done by force jail
+// override so try up won't release it
+func (l *ServicesList) Jail(id string) error {
+	l.mu.RLock()
+	i := l.healthyIndex(id)
+	var srv service.IService
+	if i != -1 {
+		srv = l.healthy[i]
+	}
+	l.mu.RUnlock()
+
+	if srv == nil {
+		return ErrServiceNotFound{ID: id}
+	}
+
+	l.logger.Info("service is manually jailed", serviceLogAttrs(srv)...)
+
+	l.SetOverride(NewOverride(OverrideForceJail, id, 0))
+
+	return nil
+}
+
+// Release manually move service with given ID from jail to
+// healthy without healthcheck, undrain it and drop its
+// force jail overrides
+func (l *ServicesList) Release(id string) error {
+	l.mu.Lock()
+
+	_, drained := l.draining[id]
+	delete(l.draining, id)
+
+	srv, jailed := l.jail[id]
+	if !jailed {
+		exists := l.healthyIndex(id) != -1
+		l.mu.Unlock()
+
+		if !exists {
+			return ErrServiceNotFound{ID: id}
+		}
+		if drained {
+			l.logger.Info("service is manually undrained", "service_id", id)
+		}
+		return nil
+	}
+
+	delete(l.jail, id)
+	for key, o := range l.overrides {
+		if key.kind == OverrideForceJail && o.Matches(srv) {
+			delete(l.overrides, key)
+			l.goExpire(key, nil)
+		}
+	}
+	if s, ok := srv.(interface{ SetStatus(service.Status) }); ok {
+		s.SetStatus(service.StatusHealthy)
+	}
+	l.healthy = append(l.healthy, srv)
+	l.notifySlots()
+
+	l.mu.Unlock()
+	l.reportCount()
+	l.stats.released(id)
+
+	l.logger.Info("service is manually released from jail", serviceLogAttrs(srv)...)
+
+	l.emit(EventReleased, srv, nil)
+
+	return nil
+}
+
+// Drain stop selecting service with given ID
+// by Next until it is released, service is
+// kept in the list and healthchecked
+func (l *ServicesList) Drain(id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.jail[id]; !ok && l.healthyIndex(id) == -1 {
+		return ErrServiceNotFound{ID: id}
+	}
+
+	l.draining[id] = struct{}{}
+	l.logger.Info("service is manually drained", "service_id", id)
+
+	return nil
+}
+
 func (l *ServicesList) SetOnSrvAddCallback(f ServiceCallbackE) {
 	if l == nil {
 		return
@@ -408,6 +1147,16 @@ func (l *ServicesList) SetOnSrvAddCallback(f ServiceCallbackE) {
 	l.onSrvAddCallback = f
 }
 
+// SetOnEventCallback set callback called
+// on every list lifecycle event
+func (l *ServicesList) SetOnEventCallback(f EventCallback) {
+	if l == nil {
+		return
+	}
+
+	l.onEventCallback = f
+}
+
 func (l *ServicesList) ModifyHealthy(modifier func(srv service.IService)) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -417,10 +1166,135 @@ func (l *ServicesList) ModifyHealthy(modifier func(srv service.IService)) {
 	}
 }
 
+// healthCheck run healthcheck of given
+// service, trace and observe its duration
+func (l *ServicesList) healthCheck(ctx context.Context, srv service.IService) error {
+	_, span := l.tracer.Start(ctx, "servicepool.HealthCheck",
+		trace.WithAttributes(attrPool.String(l.serviceName)),
+		trace.WithAttributes(serviceAttributes(srv)...))
+
+	start := time.Now()
+	err := srv.HealthCheck()
+	l.metrics.ObserveHealthCheck(l.serviceName, srv, time.Since(start), err)
+	l.stats.observeCheck(srv.ID(), err)
+
+	endSpan(span, err)
+
+	return err
+}
+
+// reportCount set healthy and jailed
+// services count metrics
+func (l *ServicesList) reportCount() {
+	l.mu.RLock()
+	healthy, jailed := len(l.healthy), len(l.jail)
+	l.mu.RUnlock()
+
+	l.metrics.SetServicesCount(l.serviceName, healthy, jailed)
+}
+
+// emit call event callback
+// with given event details
+func (l *ServicesList) emit(t EventType, srv service.IService, err error) {
+	l.emitEvent(Event{
+		Type:    t,
+		Pool:    l.serviceName,
+		Service: srv,
+		Err:     err,
+		Time:    time.Now(),
+	})
+}
+
+// emitEvent call event callback
+// with given event if it is set
+func (l *ServicesList) emitEvent(e Event) {
+	if l.onEventCallback == nil {
+		return
+	}
+
+	l.onEventCallback(e)
+}
+
+// isJailed check if given service instance
+// exist in jail taking the read lock
+func (l *ServicesList) isJailed(srv service.IService) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.isInstanceInJail(srv)
+}
+
+// goTrack run given function in background goroutine
+// awaited by Shutdown, nothing is run if list is closed
+func (l *ServicesList) goTrack(f func()) {
+	l.stopMu.Lock()
+	defer l.stopMu.Unlock()
+
+	if l.stopped {
+		return
+	}
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		f()
+	}()
+}
+
+// goTryUp start trying to up given
+// service in background goroutine
+func (l *ServicesList) goTryUp(srv service.IService) {
+	l.goTrack(func() {
+		l.TryUpService(srv, 0)
+	})
+}
+
+// goTryUpAfter start trying to up given service in
+// background goroutine after given delay
+func (l *ServicesList) goTryUpAfter(srv service.IService, delay time.Duration) {
+	l.goTrack(func() {
+		Sleep(delay, l.stopCh())
+		l.TryUpService(srv, 0)
+	})
+}
+
+// stopCh return current stop channel
+func (l *ServicesList) stopCh() <-chan struct{} {
+	l.stopMu.Lock()
+	defer l.stopMu.Unlock()
+
+	return l.stop
+}
+
+// isStopped check if list is closed
+func (l *ServicesList) isStopped() bool {
+	l.stopMu.Lock()
+	defer l.stopMu.Unlock()
+
+	return l.stopped
+}
+
+// isForceJailed check if given service is force
+// jailed by override taking the read lock
+func (l *ServicesList) isForceJailed(srv service.IService) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.isOverridden(OverrideForceJail, srv)
+}
+
+// isInstanceInJail check if exactly given service instance exist
+// in jail, because service could be replaced by its updated
+// version with the same ID
+func (l *ServicesList) isInstanceInJail(srv service.IService) bool {
+	jailed, ok := l.jail[srv.ID()]
+	return ok && jailed == srv
+}
+
 // isServiceInJail check if service exist in jail
 func (l *ServicesList) isServiceInJail(srv service.IService) bool {
 	if srv == nil {
-		logger.Log().Warn("nil srv provided when calling isServiceInJail")
+		l.logger.Warn("nil srv provided when calling isServiceInJail")
 		return false
 	}
 
@@ -435,13 +1309,13 @@ func (l *ServicesList) isServiceInJail(srv service.IService) bool {
 // healthy slice
 func (l *ServicesList) isServiceInHealthy(srv service.IService) bool {
 	if srv == nil {
-		logger.Log().Warn("nil srv provided when calling isServiceInHealthy")
+		l.logger.Warn("nil srv provided when calling isServiceInHealthy")
 		return false
 	}
 
 	for _, oldService := range l.healthy {
 		if oldService == nil {
-			logger.Log().Warn("nil oldService in healthy slice of ServicesList")
+			l.logger.Warn("nil oldService in healthy slice of ServicesList")
 			continue
 		}
 
@@ -452,6 +1326,17 @@ func (l *ServicesList) isServiceInHealthy(srv service.IService) bool {
 	return false
 }
 
+// healthyIndex return index of service with given
+// ID in healthy slice or -1 if it is not found
+func (l *ServicesList) healthyIndex(id string) int {
+	for i, srv := range l.healthy {
+		if srv != nil && srv.ID() == id {
+			return i
+		}
+	}
+	return -1
+}
+
 // nextIndex atomically increase the
 // counter and return an index
 func (l *ServicesList) nextIndex() int {
@@ -1,6 +1,9 @@
 package pool
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"testing"
@@ -76,3 +79,319 @@ func TestServicesListTryUp(t *testing.T) {
 		t.Errorf("unexpected no healthy services")
 	}
 }
+
+func TestServicesListSnapshot(t *testing.T) {
+	list := NewServicesList("name", nil)
+
+	for _, addr := range []string{"http://a", "http://b", "http://c"} {
+		srv, _ := healthySrvMutationFunc(service.NewService(addr, "", nil))
+		list.Add(srv)
+	}
+
+	first := list.Next()
+	list.FromHealthyToJail(first.ID())
+
+	snapshot := list.Snapshot()
+	if snapshot.Healthy != 2 || snapshot.Jailed != 1 || len(snapshot.Services) != 3 {
+		t.Fatalf("want 2 healthy and 1 jailed services, got %+v", snapshot)
+	}
+	if snapshot.Cursor != 1 {
+		t.Errorf("want cursor 1, got %d", snapshot.Cursor)
+	}
+
+	jailed := snapshot.Services[2]
+	if jailed.ID != first.ID() || jailed.State != StateJailed || jailed.Stats.JailedAt.IsZero() {
+		t.Errorf("jailed service is not reported correctly: %+v", jailed)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("unexpected marshal error: %s", err)
+	}
+
+	var decoded ListSnapshot
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected unmarshal error: %s", err)
+	}
+	if len(decoded.Services) != 3 || decoded.Services[2].State != StateJailed {
+		t.Errorf("snapshot is not restored from JSON: %+v", decoded)
+	}
+}
+
+func TestServicesListReportResult(t *testing.T) {
+	list := NewServicesList("name", &ServicesListOpts{PassiveFailureThreshold: 2})
+
+	var jailed int
+	list.SetOnEventCallback(func(e Event) {
+		if e.Type == EventJailed {
+			jailed++
+		}
+	})
+
+	srv := newHealthyService("http://a")
+	list.Add(srv)
+
+	list.ReportResult(srv, fmt.Errorf("failed"))
+	list.ReportResult(srv, nil)
+	list.ReportResult(srv, fmt.Errorf("failed"))
+	if jailed != 0 {
+		t.Fatalf("service is jailed before threshold is reached")
+	}
+
+	list.ReportResult(srv, fmt.Errorf("failed"))
+	if jailed != 1 {
+		t.Errorf("service is not jailed after threshold is reached")
+	}
+}
+
+func TestServicesListAcquire(t *testing.T) {
+	list := NewServicesList("name", &ServicesListOpts{MaxConcurrency: 1})
+
+	a := newHealthyService("http://a")
+	b := service.NewServiceWithOpts("http://b", &service.ServiceOpts{
+		Meta: map[string]string{MaxConcurrencyMetaKey: "2"},
+	})
+	b.(*service.BaseService).SetStatus(service.StatusHealthy)
+	list.Add(a)
+	list.Add(b)
+
+	var releases []func()
+	for i := 0; i < 3; i++ {
+		srv, release, err := list.Acquire(context.Background(), nil)
+		if err != nil || srv == nil {
+			t.Fatalf("unexpected acquire %d result: %v, %v", i, srv, err)
+		}
+		releases = append(releases, release)
+	}
+
+	if list.InFlight(a.ID()) != 1 || list.InFlight(b.ID()) != 2 {
+		t.Fatalf("unexpected in-flight counts: %d, %d", list.InFlight(a.ID()), list.InFlight(b.ID()))
+	}
+
+	if _, _, err := list.Acquire(context.Background(), nil); !errors.As(err, &ErrServicesSaturated{}) {
+		t.Fatalf("want ErrServicesSaturated, got %v", err)
+	}
+
+	queued := list.(*ServicesList)
+	queued.QueueSize = 1
+	queued.QueueTimeout = 50 * time.Millisecond
+	if _, _, err := list.Acquire(context.Background(), nil); !errors.As(err, &ErrQueueTimeout{}) {
+		t.Fatalf("want ErrQueueTimeout, got %v", err)
+	}
+
+	queued.QueueTimeout = time.Second
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		releases[0]()
+		releases[0]()
+	}()
+
+	srv, release, err := list.Acquire(context.Background(), nil)
+	if err != nil || srv == nil {
+		t.Fatalf("queued acquire is not woken up: %v, %v", srv, err)
+	}
+	release()
+
+	if total := list.InFlight(a.ID()) + list.InFlight(b.ID()); total != 2 {
+		t.Errorf("want 2 slots in flight after release, got %d", total)
+	}
+}
+
+func TestServicesListAcquireWakesOnChange(t *testing.T) {
+	list := NewServicesList("name", &ServicesListOpts{MaxConcurrency: 1, QueueSize: 1})
+
+	a, b := newHealthyService("http://a"), newHealthyService("http://b")
+	list.Add(a)
+
+	_, release, err := list.Acquire(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected acquire error: %s", err)
+	}
+	defer release()
+
+	// queued acquire has no timeout, so it is woken up only by changes
+	acquire := func(change func()) service.IService {
+		t.Helper()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			change()
+		}()
+
+		srv, _, err := list.Acquire(ctx, nil)
+		if err != nil {
+			t.Fatalf("queued acquire is not woken up: %s", err)
+		}
+		return srv
+	}
+
+	if srv := acquire(func() { list.Add(b) }); srv != b {
+		t.Errorf("want added service to be acquired, got %v", srv)
+	}
+
+	c := newHealthyService("http://c")
+	list.Add(c)
+	_ = list.Jail(c.ID())
+
+	if srv := acquire(func() { _ = list.Release(c.ID()) }); srv != c {
+		t.Errorf("want released service to be acquired, got %v", srv)
+	}
+
+	if _, _, err := list.TryAcquire(nil); !errors.As(err, &ErrServicesSaturated{}) {
+		t.Errorf("want ErrServicesSaturated without waiting, got %v", err)
+	}
+}
+
+func TestServicesListRateLimit(t *testing.T) {
+	list := NewServicesList("name", nil)
+
+	a := service.NewServiceWithOpts("http://a", &service.ServiceOpts{
+		Meta: map[string]string{RateLimitMetaKey: "0.001", RateBurstMetaKey: "2"},
+	})
+	b := service.NewService("http://b", "", map[string]struct{}{RateLimitMetaKey + "=0.001": {}})
+	for _, srv := range []service.IService{a, b} {
+		srv.(*service.BaseService).SetStatus(service.StatusHealthy)
+		list.Add(srv)
+	}
+
+	if remaining, ok := list.RateRemaining(a.ID()); !ok || remaining != 2 {
+		t.Fatalf("want 2 requests remaining, got %v, %v", remaining, ok)
+	}
+
+	selected := make(map[string]int)
+	for i := 0; i < 3; i++ {
+		srv := list.Next()
+		if srv == nil {
+			t.Fatalf("unexpected no service on call %d", i)
+		}
+		selected[srv.ID()]++
+	}
+	if selected[a.ID()] != 2 || selected[b.ID()] != 1 {
+		t.Fatalf("services are not selected by their buckets: %v", selected)
+	}
+
+	if srv := list.Next(); srv != nil {
+		t.Errorf("rate limited service %s is selected", srv.ID())
+	}
+	if remaining, ok := list.RateRemaining(b.ID()); !ok || remaining >= 1 {
+		t.Errorf("want bucket to be empty, got %v, %v", remaining, ok)
+	}
+
+	snapshot := list.Snapshot()
+	if snapshot.Services[0].RateRemaining == nil {
+		t.Errorf("rate remaining is not reported in snapshot")
+	}
+}
+
+func TestServicesListAcquireRateLimited(t *testing.T) {
+	list := NewServicesList("name", &ServicesListOpts{
+		QueueSize:    1,
+		QueueTimeout: time.Second,
+		RateLimitFunc: func(service.IService) RateLimit {
+			return RateLimit{Rate: 20, Burst: 1}
+		},
+	})
+	list.Add(newHealthyService("http://a"))
+
+	for i := 0; i < 2; i++ {
+		srv, release, err := list.Acquire(context.Background(), nil)
+		if err != nil || srv == nil {
+			t.Fatalf("unexpected acquire %d result: %v, %v", i, srv, err)
+		}
+		release()
+	}
+}
+
+func TestServicesListManualJail(t *testing.T) {
+	list := NewServicesList("name", &ServicesListOpts{TryUpTries: 5, TryUpInterval: 10 * time.Millisecond})
+	defer list.Close()
+
+	srv := &healthyService{0, newHealthyService("http://a").(*service.BaseService)}
+	list.Add(srv)
+
+	if err := list.Jail(srv.ID()); err != nil {
+		t.Fatalf("unexpected jail error: %s", err)
+	}
+
+	// service is healthy, but try up must not release it
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := list.Jailed()[srv.ID()]; !ok || len(list.Healthy()) != 0 {
+		t.Fatalf("manually jailed service is released by try up")
+	}
+
+	if err := list.Release(srv.ID()); err != nil {
+		t.Fatalf("unexpected release error: %s", err)
+	}
+	if list.Next() != srv {
+		t.Errorf("released service is not selected")
+	}
+}
+
+func TestServicesListOverrideTimers(t *testing.T) {
+	list := NewServicesList("name", nil).(*ServicesList)
+
+	timers := func() int {
+		list.stopMu.Lock()
+		defer list.stopMu.Unlock()
+		return len(list.timers)
+	}
+
+	list.SetOverride(NewOverride(OverrideExclude, "http://a", time.Hour))
+	if timers() != 1 {
+		t.Fatalf("want expiration timer for override with TTL, got %d", timers())
+	}
+
+	// replaced override must not be removed by the old timer
+	list.SetOverride(NewOverride(OverrideExclude, "http://a", 0))
+	if timers() != 0 {
+		t.Errorf("timer of replaced override is not stopped")
+	}
+
+	list.SetOverride(NewOverride(OverrideExclude, "http://a", time.Hour))
+	list.RemoveOverride(OverrideExclude, "http://a")
+	if timers() != 0 {
+		t.Errorf("timer of removed override is not stopped")
+	}
+
+	list.SetOverride(NewOverride(OverrideExclude, "http://b", time.Hour))
+	if err := list.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected shutdown error: %s", err)
+	}
+	if timers() != 0 || len(list.Overrides()) != 1 {
+		t.Errorf("timers are not stopped on shutdown or override is lost")
+	}
+
+	list.Open()
+	if timers() != 1 {
+		t.Errorf("timer is not restarted on open")
+	}
+	list.Close()
+}
+
+func TestServicesListPassiveCooldown(t *testing.T) {
+	list := NewServicesList("name", &ServicesListOpts{
+		TryUpTries:              5,
+		TryUpInterval:           10 * time.Millisecond,
+		PassiveFailureThreshold: 1,
+		PassiveCooldown:         300 * time.Millisecond,
+	})
+	defer list.Close()
+
+	// healthcheck passes while requests fail
+	srv := &healthyService{0, newHealthyService("http://a").(*service.BaseService)}
+	list.Add(srv)
+
+	list.ReportResult(srv, fmt.Errorf("failed"))
+
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := list.Jailed()[srv.ID()]; !ok {
+		t.Fatalf("service is released before passive cooldown is passed")
+	}
+
+	if !waitFor(time.Second, func() bool { return len(list.Healthy()) == 1 }) {
+		t.Errorf("service is not released after passive cooldown")
+	}
+}
@@ -1,10 +1,17 @@
 package pool
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/gateway-fm/scriptorium/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/gateway-fm/service-pool/discovery"
 	"github.com/gateway-fm/service-pool/service"
@@ -13,8 +20,9 @@ import (
 // IServicesPool holds information about reachable
 // active services, manage connections and discovery
 type IServicesPool interface {
-	// Start run service pool discovering
-	// and healthchecks loops
+	// Start run service pool discovering and healthchecks
+	// loops, it is no-op if pool is already running and
+	// restarts the pool after Close
 	Start(healthchecks bool)
 
 	// DiscoverServices discover all visible active
@@ -22,26 +30,110 @@ type IServicesPool interface {
 	DiscoverServices() error
 
 	// NextService returns next active service
-	// to take a connection
+	// to take a connection or nil if there is no one
 	NextService() service.IService
 
+	// NextServiceE returns next active service to take
+	// a connection or error describing why there is no one
+	NextServiceE() (service.IService, error)
+
+	// AcquireService returns next active service which is not saturated
+	// and takes its concurrency slot until release is called, if all
+	// the services are saturated it waits in the list queue
+	AcquireService(ctx context.Context) (service.IService, func(), error)
+
+	// AcquireServiceFiltered returns next active service which is
+	// not saturated and not skipped by given function and takes its
+	// concurrency slot until release is called
+	AcquireServiceFiltered(ctx context.Context, skip func(srv service.IService) bool) (service.IService, func(), error)
+
+	// NextServiceContext returns next active service
+	// to take a connection, selection is traced
+	// as a child span of given context
+	NextServiceContext(ctx context.Context) service.IService
+
+	// Do call given function with next active service retrying it
+	// on other services by given policy (default if nil)
+	Do(ctx context.Context, fn AttemptFunc, policy *RetryPolicy) error
+
+	// Hedge call given function with next active service and send
+	// the same call to another service if it doesn't finish within
+	// policy (default if nil) delay, the first success is used
+	Hedge(ctx context.Context, fn AttemptFunc, policy *HedgePolicy) error
+
+	// ReportResult report result of the request made to
+	// given service for passive health checking
+	ReportResult(srv service.IService, err error)
+
+	// ReportLatency report latency of the request made to
+	// given service for latency aware selection
+	ReportLatency(srv service.IService, d time.Duration)
+
 	// Count return numbers of
 	// all healthy services in pool
 	Count() int
 
+	// WaitReady block until at least minHealthy services can
+	// be selected by NextService, ErrNotReady is returned if
+	// given context is done before, minHealthy <= 0 returns at once
+	WaitReady(ctx context.Context, minHealthy int) error
+
+	// Name return service name of the pool
+	Name() string
+
+	// DiscoveryStatus return result
+	// of the discovery rounds
+	DiscoveryStatus() DiscoveryStatus
+
 	// List return ServicesPool ServicesList instance
 	List() IServicesList
 
-	// Close Stop all service pool
+	// Close Stop all service pool, it is
+	// safe to call Close several times
 	Close()
 
+	// Shutdown Stop all service pool, wait for all its
+	// background goroutines and close all the services
+	Shutdown(ctx context.Context) error
+
+	// Exclude stop selecting service with given ID or
+	// address for given ttl (zero or negative for no limit)
+	Exclude(target string, ttl time.Duration)
+
+	// ForceJail move service with given ID or address to jail and keep
+	// it there for given ttl (zero or negative for no limit)
+	ForceJail(target string, ttl time.Duration)
+
+	// Pin restrict selection to services with given IDs or
+	// addresses for given ttl (zero or negative for no limit)
+	Pin(ttl time.Duration, targets ...string)
+
+	// ClearOverride remove override with given kind and target
+	ClearOverride(kind OverrideKind, target string) bool
+
+	// Overrides return all active overrides
+	Overrides() []Override
+
 	SetOnNewDiscCallback(f ServiceCallbackE)
 
 	SetOnDiscRemoveCallback(f ServiceCallback)
 
+	SetOnDiscUpdateCallback(f ServiceUpdateCallback)
+
 	SetOnDiscCompletedCallback(f func())
 
 	SetMutationNeededCallback(f ServiceCallbackB)
+
+	SetOnMassRemovalCallback(f MassRemovalCallback)
+
+	// Subscribe return channel of pool lifecycle events
+	// and function to cancel the subscription. Events are
+	// dropped if subscriber doesn't read them in time
+	Subscribe() (<-chan Event, func())
+
+	// DroppedEvents return number of events dropped
+	// due to subscribers full channels
+	DroppedEvents() uint64
 }
 
 // ServicesPool holds information about reachable
@@ -57,15 +149,44 @@ type ServicesPool struct {
 
 	stop chan struct{}
 
+	lifecycleMu sync.Mutex
+	running     bool
+	wg          sync.WaitGroup
+
+	started atomic.Bool
+	closed  atomic.Bool
+
 	MutationFnc func(srv service.IService) (service.IService, error)
 
 	onNewDiscCallback ServiceCallbackE
 
 	onDiscRemoveCallback ServiceCallback
 
+	onDiscUpdateCallback ServiceUpdateCallback
+
 	onDiscCompletedCallback func()
 
 	mutationNeededCallback ServiceCallbackB
+
+	onMassRemovalCallback MassRemovalCallback
+
+	events *eventBus
+
+	latency *latencyWindow
+	hedges  *hedgeBudget
+
+	metrics MetricsCollector
+	tracer  trace.Tracer
+	logger  *slog.Logger
+
+	discoveryMu sync.Mutex
+
+	statusMu        sync.RWMutex
+	discoveryStatus DiscoveryStatus
+
+	removalThreshold     float64
+	removalConfirmRounds int
+	missingRounds        map[string]int // number of consecutive rounds service is missing in discovery
 }
 
 // ServicesPoolsOpts is options that needs
@@ -79,12 +200,41 @@ type ServicesPoolsOpts struct {
 	MutationFnc func(srv service.IService) (service.IService, error)
 
 	CustomList IServicesList
+
+	// RemovalThreshold is max share (0..1) of known services that can be removed
+	// by single discovery round, removal above it is delayed until confirmed (0 to disable)
+	RemovalThreshold float64
+	// RemovalConfirmRounds is number of consecutive discovery rounds service should be
+	// missing to be removed when RemovalThreshold is exceeded (default is 3)
+	RemovalConfirmRounds int
+
+	EventsBufferSize int // size of every events subscriber channel buffer (default is 64)
+
+	Metrics MetricsCollector // optional metrics collector, also used by the list if it has no own one
+
+	TracerProvider trace.TracerProvider // optional OpenTelemetry tracer provider, also used by the list if it has no own one
+	MeterProvider  metric.MeterProvider // optional OpenTelemetry meter provider
+
+	Logger *slog.Logger // optional logger, slog.Default() is used if nil, also used by the list if it has no own one
 }
 
 type ServiceCallbackE func(srv service.IService) error
 type ServiceCallback func(srv service.IService)
 type ServiceCallbackB func(srv service.IService) bool
 
+// ServiceUpdateCallback is called when rediscovered service
+// with changed attributes replaces the old one in the list
+type ServiceUpdateCallback func(old, new service.IService)
+
+// MassRemovalCallback is called when discovery round would remove
+// more than allowed share of known services, pending are services
+// which removal is delayed and known is number of known services
+type MassRemovalCallback func(pending []service.IService, known int)
+
+// defaultRemovalConfirmRounds is default number of consecutive
+// discovery rounds to confirm mass removal of services
+const defaultRemovalConfirmRounds = 3
+
 // NewServicesPool create new Services Pool
 // based on given params
 func NewServicesPool(opts *ServicesPoolsOpts) IServicesPool {
@@ -94,113 +244,354 @@ func NewServicesPool(opts *ServicesPoolsOpts) IServicesPool {
 		name:              opts.Name,
 		stop:              make(chan struct{}),
 		MutationFnc:       opts.MutationFnc,
+
+		removalThreshold:     opts.RemovalThreshold,
+		removalConfirmRounds: opts.RemovalConfirmRounds,
+		missingRounds:        make(map[string]int),
+
+		events:  newEventBus(opts.EventsBufferSize),
+		latency: newLatencyWindow(),
+		hedges:  newHedgeBudget(),
+		tracer:  newTracer(opts.TracerProvider),
+	}
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	pool.logger = logger.With("pool", opts.Name)
+
+	var otelCollector MetricsCollector
+	if opts.MeterProvider != nil {
+		collector, err := newOtelMetrics(opts.MeterProvider)
+		if err != nil {
+			pool.logger.Warn("OpenTelemetry metrics are disabled", "error", err)
+		} else {
+			otelCollector = collector
+		}
+	}
+	pool.metrics = newMultiMetrics(opts.Metrics, otelCollector)
+
+	if pool.removalConfirmRounds <= 0 {
+		pool.removalConfirmRounds = defaultRemovalConfirmRounds
 	}
 
 	if opts.CustomList != nil {
 		pool.list = opts.CustomList
 	} else {
-		pool.list = NewServicesList(opts.Name, opts.ListOpts)
+		listOpts := &ServicesListOpts{}
+		if opts.ListOpts != nil {
+			*listOpts = *opts.ListOpts
+		}
+		if listOpts.Metrics == nil {
+			listOpts.Metrics = pool.metrics
+		}
+		if listOpts.TracerProvider == nil {
+			listOpts.TracerProvider = opts.TracerProvider
+		}
+		if listOpts.Logger == nil {
+			listOpts.Logger = opts.Logger
+		}
 
+		pool.list = NewServicesList(opts.Name, listOpts)
 	}
 
+	pool.list.SetOnEventCallback(pool.events.publish)
+
 	return pool
 }
 
-// Start run service pool discovering
-// and healthchecks loops
+// Start run service pool discovering and healthchecks
+// loops, it is no-op if pool is already running and
+// restarts the pool after Close when loops of the
+// previous run are finished
 func (p *ServicesPool) Start(healthchecks bool) {
-	go p.discoverServicesLoop()
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+
+	if p.running {
+		p.logger.Warn("pool is already started")
+		return
+	}
+
+	if p.closed.Load() {
+		// loops of the previous run must exit on the old
+		// stop channels before the list is reopened
+		p.wg.Wait()
+
+		p.stop = make(chan struct{})
+		p.list.Open()
+		p.closed.Store(false)
+	}
+
+	p.running = true
+	p.started.Store(true)
+
+	stop := p.stop
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.discoverServicesLoop(stop)
+	}()
 
 	if healthchecks {
-		go p.list.HealthChecksLoop()
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.list.HealthChecksLoop()
+		}()
 	}
 }
 
 // DiscoverServices discover all visible active
 // services via service-discovery
-func (p *ServicesPool) DiscoverServices() error {
+func (p *ServicesPool) DiscoverServices() (err error) {
+	p.discoveryMu.Lock()
+	defer p.discoveryMu.Unlock()
+
+	ctx, span := p.tracer.Start(context.Background(), "servicepool.DiscoverServices",
+		trace.WithAttributes(attrPool.String(p.name)))
+	defer func() { endSpan(span, err) }()
+
+	start := time.Now()
 	newServices, err := p.discovery.Discover(p.name)
+	p.metrics.ObserveDiscovery(p.name, time.Since(start), err)
 	if err != nil {
-		return fmt.Errorf("error discovering %s active: %w", p.name, err)
+		err = fmt.Errorf("error discovering %s active: %w", p.name, err)
+		p.setDiscoveryStatus(err)
+		p.emit(EventDiscoveryError, nil, err)
+		return err
 	}
+	p.setDiscoveryStatus(nil)
 
-	// construct map of newly discovered IDs
-	// time complexity is O(len(newServices))
-	newlyDiscoveredIDs := make(map[string]struct{})
-	for _, newService := range newServices {
-		newlyDiscoveredIDs[newService.ID()] = struct{}{}
+	diff := p.diffServices(newServices)
+	p.list.ApplyDiff(ctx, diff)
+
+	span.SetAttributes(
+		attribute.Int("servicepool.discovered", len(newServices)),
+		attribute.Int("servicepool.added", len(diff.Added)),
+		attribute.Int("servicepool.removed", len(diff.Removed)),
+		attribute.Int("servicepool.updated", len(diff.Updated)),
+	)
+
+	for _, srv := range diff.Removed {
+		p.emit(EventRemoved, srv, nil)
+	}
+	for _, update := range diff.Updated {
+		p.emit(EventUpdated, update.New, nil)
 	}
 
-	// for every health service check whether it was discovered lastly
-	// if not -- remove it from healthy
-	// time complexity is O(len(healthy)) + O(1)
-	for index, srv := range p.list.Healthy() {
-		if _, wasDiscovered := newlyDiscoveredIDs[srv.ID()]; !wasDiscovered {
-			p.list.RemoveFromHealthyByIndex(index)
+	if p.onDiscRemoveCallback != nil {
+		for _, srv := range diff.Removed {
+			p.onDiscRemoveCallback(srv)
+		}
+	}
 
-			if p.onDiscRemoveCallback != nil {
-				p.onDiscRemoveCallback(srv)
-			}
-			break
+	if p.onDiscUpdateCallback != nil {
+		for _, update := range diff.Updated {
+			p.onDiscUpdateCallback(update.Old, update.New)
 		}
 	}
 
-	// for every jailed service check whether it was discovered lastly
-	// if not -- remove it from jailed
-	// time complexity is O(len(jailed)) + O(1)
-	for srvID, srv := range p.list.Jailed() {
-		if _, wasDiscovered := newlyDiscoveredIDs[srvID]; !wasDiscovered {
-			p.list.RemoveFromJail(srv)
+	return nil
+}
 
-			if p.onDiscRemoveCallback != nil {
-				p.onDiscRemoveCallback(srv)
-			}
-			break
-		}
+// diffServices compare newly discovered services with the
+// services in the list and return discovery diff keyed by
+// service ID, new services are mutated before adding
+func (p *ServicesPool) diffServices(newServices []service.IService) *DiscoveryDiff {
+	known := make(map[string]service.IService)
+	for _, srv := range p.list.Healthy() {
+		known[srv.ID()] = srv
+	}
+	for id, srv := range p.list.Jailed() {
+		known[id] = srv
 	}
-	// the total complexity looks like O(n), but not O(n^2) :D
 
-	// TODO for the best scaling we need to change this part to map-based compare mechanic
+	diff := &DiscoveryDiff{}
+
+	// construct map of newly discovered services
+	// time complexity is O(len(newServices))
+	discovered := make(map[string]service.IService, len(newServices))
+	ordered := make([]service.IService, 0, len(newServices))
 	for _, newService := range newServices {
 		if newService == nil {
-			logger.Log().Warn("newService is nil during discovery")
+			p.logger.Warn("newService is nil during discovery")
+			continue
+		}
+		if _, duplicate := discovered[newService.ID()]; duplicate {
 			continue
 		}
+		discovered[newService.ID()] = newService
+		ordered = append(ordered, newService)
+	}
+
+	for _, srv := range p.servicesToRemove(known, discovered) {
+		diff.Removed = append(diff.Removed, srv)
+	}
 
-		// if service doesn't exist in pool or if the callback returns true --
-		// then we do a mutation.
+	for _, newService := range ordered {
+		// if service doesn't exist in pool, its discovery attributes are changed
+		// or if the callback returns true -- then we do a mutation.
 		// otherwise we prefer not to mutate srv to prevent spawning unnecessary goroutines
-		isServiceExists := p.list.IsServiceExists(newService)
-		weNeedToMutate := !isServiceExists || (p.mutationNeededCallback != nil && p.mutationNeededCallback(newService))
-		var mutatedService service.IService
-
-		if weNeedToMutate {
-			mutatedService, err = p.MutationFnc(newService)
-			if err != nil {
-				logger.Log().Warn(fmt.Sprintf("mutate new discovered service: %s", err))
-				continue
-			}
+		oldService, isServiceExists := known[newService.ID()]
+		isChanged := isServiceExists && serviceChanged(oldService, newService)
+		weNeedToMutate := !isServiceExists || isChanged ||
+			(p.mutationNeededCallback != nil && p.mutationNeededCallback(newService))
+		if !weNeedToMutate {
+			continue
+		}
 
-			if p.onNewDiscCallback != nil {
-				if err := p.onNewDiscCallback(mutatedService); err != nil {
-					logger.Log().Warn(fmt.Sprintf("callback on new discovered service: %s", err))
-				}
+		mutatedService, err := p.MutationFnc(newService)
+		if err != nil {
+			p.logger.Warn("mutate new discovered service", append(serviceLogAttrs(newService), "error", err)...)
+			continue
+		}
+
+		if p.onNewDiscCallback != nil {
+			if err := p.onNewDiscCallback(mutatedService); err != nil {
+				p.logger.Warn("callback on new discovered service", append(serviceLogAttrs(mutatedService), "error", err)...)
 			}
 		}
 
+		// only changed services replace the live instance, the
+		// callback just re-runs the mutation and the callbacks
+		if isChanged {
+			diff.Updated = append(diff.Updated, ServiceUpdate{Old: oldService, New: mutatedService})
+			continue
+		}
 		if isServiceExists {
 			continue
 		}
-		p.list.Add(mutatedService)
+		diff.Added = append(diff.Added, mutatedService)
 	}
-	return nil
+
+	return diff
 }
 
 // NextService returns next active service
-// to take a connection
+// to take a connection or nil if there is no one
 func (p *ServicesPool) NextService() service.IService {
-	// TODO maybe is better to return error if next service is nill
-	return p.list.Next()
+	return p.NextServiceContext(context.Background())
+}
+
+// NextServiceE returns next active service to take
+// a connection or error describing why there is no one
+func (p *ServicesPool) NextServiceE() (service.IService, error) {
+	return p.nextService(context.Background(), nil)
+}
+
+// NextServiceContext returns next active service
+// to take a connection, selection is traced
+// as a child span of given context
+func (p *ServicesPool) NextServiceContext(ctx context.Context) service.IService {
+	srv, _ := p.nextService(ctx, nil)
+	return srv
+}
+
+// ReportResult report result of the request made to
+// given service for passive health checking
+func (p *ServicesPool) ReportResult(srv service.IService, err error) {
+	p.list.ReportResult(srv, err)
+}
+
+// ReportLatency report latency of the request made to
+// given service for latency aware selection
+func (p *ServicesPool) ReportLatency(srv service.IService, d time.Duration) {
+	p.list.ReportLatency(srv, d)
+}
+
+// nextService returns next active service to take a connection which
+// is not skipped by given function or error describing why there is
+// no one, selection is traced as a child span of given context
+func (p *ServicesPool) nextService(ctx context.Context, skip func(srv service.IService) bool) (service.IService, error) {
+	srv, _, err := p.selectService(ctx, skip, selectNext)
+	return srv, err
+}
+
+// AcquireService returns next active service which is not saturated
+// and takes its concurrency slot until release is called, if all
+// the services are saturated it waits in the list queue
+func (p *ServicesPool) AcquireService(ctx context.Context) (service.IService, func(), error) {
+	return p.acquireService(ctx, nil)
+}
+
+// AcquireServiceFiltered returns next active service which is
+// not saturated and not skipped by given function and takes its
+// concurrency slot until release is called, if all the services
+// are saturated it waits in the list queue
+func (p *ServicesPool) AcquireServiceFiltered(ctx context.Context, skip func(srv service.IService) bool) (service.IService, func(), error) {
+	return p.acquireService(ctx, skip)
+}
+
+// acquireService returns next active service which is not
+// skipped by given function and takes its concurrency slot
+func (p *ServicesPool) acquireService(ctx context.Context, skip func(srv service.IService) bool) (service.IService, func(), error) {
+	return p.selectService(ctx, skip, selectAcquire)
+}
+
+// tryAcquireService returns next active service which is not skipped
+// by given function and takes its concurrency slot without waiting in
+// the list queue
+func (p *ServicesPool) tryAcquireService(ctx context.Context, skip func(srv service.IService) bool) (service.IService, func(), error) {
+	return p.selectService(ctx, skip, selectTryAcquire)
+}
+
+// selection is the way
+// service is selected
+type selection int
+
+const (
+	selectNext       selection = iota // select service without taking its slot
+	selectAcquire                     // take service slot waiting in the queue
+	selectTryAcquire                  // take service slot failing if it is saturated
+)
+
+// selectService returns next active service which is not skipped by
+// given function taking its concurrency slot by given selection or
+// error describing why there is no one, selection is traced as a
+// child span of given context
+func (p *ServicesPool) selectService(ctx context.Context, skip func(srv service.IService) bool, how selection) (srv service.IService, release func(), err error) {
+	ctx, span := p.tracer.Start(ctx, "servicepool.NextService",
+		trace.WithAttributes(attrPool.String(p.name)))
+	defer span.End()
+
+	if p.closed.Load() {
+		span.SetAttributes(attrOutcome.String(outcomeEmpty))
+		return nil, nil, ErrPoolClosed{Pool: p.name}
+	}
+
+	switch how {
+	case selectAcquire:
+		srv, release, err = p.list.Acquire(ctx, skip)
+	case selectTryAcquire:
+		srv, release, err = p.list.TryAcquire(skip)
+	default:
+		srv, err = p.list.NextFilteredE(skip)
+	}
+	if err != nil {
+		p.metrics.IncNextNil(p.name)
+		span.SetAttributes(attrOutcome.String(outcomeEmpty))
+		return nil, nil, err
+	}
+
+	if srv == nil {
+		p.metrics.IncNextNil(p.name)
+		span.SetAttributes(attrOutcome.String(outcomeEmpty))
+
+		healthy, jailed := len(p.list.Healthy()), len(p.list.Jailed())
+		if healthy+jailed == 0 && !p.started.Load() {
+			return nil, nil, ErrPoolNotStarted{Pool: p.name}
+		}
+
+		return nil, nil, ErrNoHealthyServices{Pool: p.name, Jailed: jailed, Known: healthy + jailed}
+	}
+
+	span.SetAttributes(serviceAttributes(srv)...)
+	span.SetAttributes(attrOutcome.String(outcomeSuccess))
+
+	return srv, release, nil
 }
 
 // Count return numbers of
@@ -209,17 +600,135 @@ func (p *ServicesPool) Count() int {
 	return len(p.list.Healthy())
 }
 
+// readyCheckInterval is interval to check the pool
+// readiness if there are no events between checks
+const readyCheckInterval = 50 * time.Millisecond
+
+// WaitReady block until at least minHealthy services can
+// be selected by NextService, ErrNotReady is returned if
+// given context is done before. Non-positive minHealthy
+// requires no services, so nil is returned at once
+func (p *ServicesPool) WaitReady(ctx context.Context, minHealthy int) error {
+	if minHealthy <= 0 {
+		return nil
+	}
+
+	events, cancel := p.Subscribe()
+	defer cancel()
+
+	ticker := time.NewTicker(readyCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		snapshot := p.list.Snapshot()
+		if snapshot.Routable() >= minHealthy {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ErrNotReady{
+				Pool:       p.name,
+				MinHealthy: minHealthy,
+				Routable:   snapshot.Routable(),
+				Jailed:     snapshot.Jailed,
+				Known:      len(snapshot.Services),
+				Err:        ctx.Err(),
+			}
+		case <-events:
+		case <-ticker.C:
+		}
+	}
+}
+
 // List return ServicesPool ServicesList instance
 func (p *ServicesPool) List() IServicesList {
 	return p.list
 }
 
-// Close Stop all service pool
+// Name return service name of the pool
+func (p *ServicesPool) Name() string {
+	return p.name
+}
+
+// DiscoveryStatus return result
+// of the discovery rounds
+func (p *ServicesPool) DiscoveryStatus() DiscoveryStatus {
+	p.statusMu.RLock()
+	defer p.statusMu.RUnlock()
+
+	return p.discoveryStatus
+}
+
+// Close Stop all service pool, it is
+// safe to call Close several times
 func (p *ServicesPool) Close() {
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+
+	if p.closed.Load() {
+		return
+	}
+
+	p.closed.Store(true)
+	p.running = false
+
 	p.list.Close()
 	close(p.stop)
 }
 
+// Shutdown Stop all service pool, wait for all its background
+// goroutines and close all the services. Services are closed
+// even if context is done before goroutines finish
+func (p *ServicesPool) Shutdown(ctx context.Context) error {
+	p.Close()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("wait for %s pool goroutines: %w", p.name, ctx.Err())
+	}
+
+	return errors.Join(err, p.list.Shutdown(ctx))
+}
+
+// Exclude stop selecting service with given ID or
+// address for given ttl (zero or negative for no limit)
+func (p *ServicesPool) Exclude(target string, ttl time.Duration) {
+	p.list.SetOverride(NewOverride(OverrideExclude, target, ttl))
+}
+
+// ForceJail move service with given ID or address to jail and keep
+// it there for given ttl (zero or negative for no limit)
+func (p *ServicesPool) ForceJail(target string, ttl time.Duration) {
+	p.list.SetOverride(NewOverride(OverrideForceJail, target, ttl))
+}
+
+// Pin restrict selection to services with given IDs or
+// addresses for given ttl (zero or negative for no limit)
+func (p *ServicesPool) Pin(ttl time.Duration, targets ...string) {
+	for _, target := range targets {
+		p.list.SetOverride(NewOverride(OverridePin, target, ttl))
+	}
+}
+
+// ClearOverride remove override with given kind and target
+func (p *ServicesPool) ClearOverride(kind OverrideKind, target string) bool {
+	return p.list.RemoveOverride(kind, target)
+}
+
+// Overrides return all active overrides
+func (p *ServicesPool) Overrides() []Override {
+	return p.list.Overrides()
+}
+
 func (p *ServicesPool) SetOnNewDiscCallback(f ServiceCallbackE) {
 	if p == nil {
 		return
@@ -244,6 +753,14 @@ func (p *ServicesPool) SetOnDiscRemoveCallback(f ServiceCallback) {
 	p.onDiscRemoveCallback = f
 }
 
+func (p *ServicesPool) SetOnDiscUpdateCallback(f ServiceUpdateCallback) {
+	if p == nil {
+		return
+	}
+
+	p.onDiscUpdateCallback = f
+}
+
 func (p *ServicesPool) SetMutationNeededCallback(f ServiceCallbackB) {
 	if p == nil {
 		return
@@ -252,20 +769,116 @@ func (p *ServicesPool) SetMutationNeededCallback(f ServiceCallbackB) {
 	p.mutationNeededCallback = f
 }
 
+func (p *ServicesPool) SetOnMassRemovalCallback(f MassRemovalCallback) {
+	if p == nil {
+		return
+	}
+
+	p.onMassRemovalCallback = f
+}
+
+// Subscribe return channel of pool lifecycle events
+// and function to cancel the subscription. Events are
+// dropped if subscriber doesn't read them in time
+func (p *ServicesPool) Subscribe() (<-chan Event, func()) {
+	return p.events.subscribe()
+}
+
+// DroppedEvents return number of events dropped
+// due to subscribers full channels
+func (p *ServicesPool) DroppedEvents() uint64 {
+	return p.events.dropped.Load()
+}
+
+// emit publish pool lifecycle
+// event to all the subscribers
+func (p *ServicesPool) emit(t EventType, srv service.IService, err error) {
+	p.events.publish(Event{
+		Type:    t,
+		Pool:    p.name,
+		Service: srv,
+		Err:     err,
+		Time:    time.Now(),
+	})
+}
+
+// setDiscoveryStatus save result
+// of the discovery round
+func (p *ServicesPool) setDiscoveryStatus(err error) {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+
+	if err != nil {
+		p.discoveryStatus.LastError = err.Error()
+		p.discoveryStatus.LastErrorAt = time.Now()
+		return
+	}
+
+	p.discoveryStatus.LastSuccess = time.Now()
+}
+
+// servicesToRemove return known services missing in discovered
+// ones which can be removed from the list. If share of missing
+// services exceeds removal threshold, service is returned
+// only after it is missing for confirm rounds in a row
+func (p *ServicesPool) servicesToRemove(known, discovered map[string]service.IService) map[string]service.IService {
+	missing := make(map[string]service.IService)
+	for id, srv := range known {
+		if _, wasDiscovered := discovered[id]; !wasDiscovered {
+			missing[id] = srv
+		}
+	}
+
+	if p.removalThreshold <= 0 || len(known) == 0 ||
+		float64(len(missing))/float64(len(known)) <= p.removalThreshold {
+		p.missingRounds = make(map[string]int)
+		return missing
+	}
+
+	rounds := make(map[string]int, len(missing))
+	toRemove := make(map[string]service.IService)
+	var pending []service.IService
+
+	for id, srv := range missing {
+		rounds[id] = p.missingRounds[id] + 1
+
+		if rounds[id] >= p.removalConfirmRounds {
+			toRemove[id] = srv
+			continue
+		}
+		pending = append(pending, srv)
+	}
+	p.missingRounds = rounds
+
+	if len(pending) != 0 {
+		p.logger.Warn("discovery would remove too many known services, removal is delayed until confirmed", "missing", len(missing), "known", len(known), "pending", len(pending))
+
+		for _, srv := range pending {
+			p.emit(EventRemovalDelayed, srv, nil)
+		}
+
+		if p.onMassRemovalCallback != nil {
+			p.onMassRemovalCallback(pending, len(known))
+		}
+	}
+
+	return toRemove
+}
+
 // discoverServicesLoop spawn discovery for
 // services periodically
-func (p *ServicesPool) discoverServicesLoop() {
-	logger.Log().Info("start discovery loop")
+func (p *ServicesPool) discoverServicesLoop(stop <-chan struct{}) {
+	p.logger.Info("start discovery loop")
 
 	onceShuffled := false
 	for {
 		select {
-		case <-p.stop:
-			logger.Log().Warn("Stop discovery loop")
+		case <-stop:
+			p.logger.Warn("stop discovery loop")
 			return
 		default:
 			if err := p.DiscoverServices(); err != nil {
-				logger.Log().Warn(fmt.Errorf("error discovery services: %w", err).Error())
+				p.logger.Warn("error discovery services", "error", err)
 			}
 
 			// sync.Once won't work in cases when we call Start() then Close()
@@ -279,7 +892,7 @@ func (p *ServicesPool) discoverServicesLoop() {
 				}
 			}
 
-			Sleep(p.discoveryInterval, p.stop)
+			Sleep(p.discoveryInterval, stop)
 		}
 	}
 }
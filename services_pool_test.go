@@ -1,6 +1,12 @@
 package pool
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -169,3 +175,424 @@ func TestServicesPoolCallbacks(t *testing.T) {
 		t.Errorf("onDiscCompletedCallback was not executed")
 	}
 }
+
+func TestServicesPoolMassRemovalProtection(t *testing.T) {
+	pool, disc := newStaticPoolWithOpts(&ServicesPoolsOpts{
+		ListOpts:             &ServicesListOpts{TryUpTries: 1},
+		RemovalThreshold:     0.5,
+		RemovalConfirmRounds: 2,
+	}, "http://a", "http://b", "http://c", "http://d")
+
+	var pendingReported int
+	pool.SetOnMassRemovalCallback(func(pending []service.IService, known int) {
+		pendingReported = len(pending)
+	})
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	disc.SetAddresses("http://a")
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	if pool.Count() != 4 {
+		t.Errorf("services were removed before confirmation, got %d", pool.Count())
+	}
+
+	if pendingReported != 3 {
+		t.Errorf("want 3 pending services reported, got %d", pendingReported)
+	}
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	if pool.Count() != 1 {
+		t.Errorf("confirmed removal was not applied, got %d services", pool.Count())
+	}
+}
+
+func TestServicesPoolDiscoveryReconciliation(t *testing.T) {
+	pool, disc := newStaticPool(&ServicesListOpts{TryUpTries: 1}, "http://a", "http://b", "http://c", "http://d")
+
+	var removed []string
+	pool.SetOnDiscRemoveCallback(func(srv service.IService) {
+		removed = append(removed, srv.Address())
+	})
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	disc.SetAddresses("http://b", "http://e")
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	if len(removed) != 3 {
+		t.Errorf("want 3 removed services in one round, got %d", len(removed))
+	}
+
+	got := make(map[string]struct{})
+	for _, srv := range pool.List().Healthy() {
+		got[srv.Address()] = struct{}{}
+	}
+
+	_, hasB := got["http://b"]
+	_, hasE := got["http://e"]
+	if len(got) != 2 || !hasB || !hasE {
+		t.Errorf("unexpected services after reconciliation: %v", got)
+	}
+}
+
+func TestServicesPoolDiscoveryUpdate(t *testing.T) {
+	pool, disc := newStaticPool(&ServicesListOpts{TryUpTries: 1}, "http://a", "http://b")
+
+	var updated []string
+	pool.SetOnDiscUpdateCallback(func(old, new service.IService) {
+		updated = append(updated, new.Address())
+	})
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	// rediscovery without changes must not update services
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+	if len(updated) != 0 {
+		t.Errorf("unexpected updates without changes: %v", updated)
+	}
+
+	disc.SetTags("archive")
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	if len(updated) != 2 {
+		t.Errorf("want 2 updated services, got %d", len(updated))
+	}
+
+	if pool.Count() != 2 {
+		t.Errorf("want 2 services after update, got %d", pool.Count())
+	}
+
+	for _, srv := range pool.List().Healthy() {
+		if _, ok := srv.Tags()["archive"]; !ok {
+			t.Errorf("service %s was not updated", srv.Address())
+		}
+	}
+}
+
+func TestServicesPoolMutationNeeded(t *testing.T) {
+	pool, _ := newStaticPoolWithOpts(&ServicesPoolsOpts{
+		ListOpts: &ServicesListOpts{TryUpTries: 1},
+		MutationFnc: func(srv service.IService) (service.IService, error) {
+			return &closeTrackingService{IService: newHealthyService(srv.Address())}, nil
+		},
+	}, "http://a")
+
+	var mutated int
+	pool.SetOnNewDiscCallback(func(service.IService) error {
+		mutated++
+		return nil
+	})
+	pool.SetMutationNeededCallback(func(service.IService) bool { return true })
+
+	var updated int
+	pool.SetOnDiscUpdateCallback(func(_, _ service.IService) { updated++ })
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+	live := pool.List().Healthy()[0].(*closeTrackingService)
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	if mutated != 2 {
+		t.Errorf("want mutation to be re-run, got %d mutations", mutated)
+	}
+	if updated != 0 {
+		t.Errorf("unexpected %d updates of unchanged service", updated)
+	}
+	if srv := pool.List().Healthy()[0]; srv != live || live.closed.Load() {
+		t.Errorf("live service is replaced or closed")
+	}
+}
+
+func TestServicesPoolLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	pool, _ := newStaticPoolWithOpts(&ServicesPoolsOpts{
+		MutationFnc: dummyMutationFunc,
+		Logger:      logger,
+	}, "http://a")
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(bytes.Split(buf.Bytes(), []byte("\n"))[0], &record); err != nil {
+		t.Fatalf("unexpected log record: %s", err)
+	}
+
+	if record["pool"] != "TestServicePool" || record["address"] != "http://a" || record["service_id"] == nil {
+		t.Errorf("log record has no structured service fields: %v", record)
+	}
+
+	// discovered service has UnHealthy status, Next must not log on Info level
+	buf.Reset()
+	pool.NextService()
+
+	if buf.Len() != 0 {
+		t.Errorf("unexpected Info log during NextService: %s", buf.String())
+	}
+}
+
+// TestServicesPoolOverrides tests that operator overrides
+// are applied to selection and survive rediscovery
+func TestServicesPoolOverrides(t *testing.T) {
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1}, "http://a", "http://b", "http://c")
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	pool.Exclude("http://a", 50*time.Millisecond)
+	pool.ForceJail("http://c", 0)
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	if _, ok := pool.List().Jailed()[service.NewService("http://c", "", nil).ID()]; !ok {
+		t.Fatalf("force jailed service is not in jail after rediscovery")
+	}
+	for i := 0; i < 4; i++ {
+		if srv := pool.NextService(); srv == nil || srv.Address() != "http://b" {
+			t.Fatalf("want only http://b to be selected, got %v", srv)
+		}
+	}
+
+	if overrides := pool.List().Snapshot().Overrides; len(overrides) != 2 {
+		t.Errorf("want 2 overrides in snapshot, got %d", len(overrides))
+	}
+
+	// wait until exclusion is expired
+	if !waitFor(time.Second, func() bool { return len(pool.Overrides()) == 1 }) {
+		t.Fatalf("exclusion is not expired")
+	}
+
+	pool.Pin(0, "http://a")
+	for i := 0; i < 4; i++ {
+		if srv := pool.NextService(); srv == nil || srv.Address() != "http://a" {
+			t.Fatalf("want only pinned http://a to be selected, got %v", srv)
+		}
+	}
+
+	if !pool.ClearOverride(OverrideForceJail, "http://c") {
+		t.Fatalf("force jail override was not found")
+	}
+
+	if !waitFor(time.Second, func() bool { return len(pool.List().Jailed()) == 0 }) {
+		t.Errorf("service is not released after force jail override is cleared")
+	}
+}
+
+// TestServicesPoolWaitReady tests that WaitReady returns once
+// discovery is finished or fails with descriptive error
+func TestServicesPoolWaitReady(t *testing.T) {
+	pool, _ := newStaticPoolWithOpts(&ServicesPoolsOpts{
+		ListOpts:          &ServicesListOpts{TryUpTries: 1},
+		DiscoveryInterval: time.Hour,
+	}, "http://a", "http://b")
+	pool.Start(false)
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := pool.WaitReady(ctx, 2); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := pool.WaitReady(ctx, 3)
+
+	var notReady ErrNotReady
+	if !errors.As(err, &notReady) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want ErrNotReady wrapping deadline error, got %v", err)
+	}
+	if notReady.Routable != 2 || notReady.MinHealthy != 3 {
+		t.Errorf("unexpected error details: %+v", notReady)
+	}
+
+	// no services are required, so even empty pool with done context is ready
+	empty, _ := newStaticPool(nil)
+	if err := empty.WaitReady(ctx, 0); err != nil {
+		t.Errorf("unexpected error for zero min healthy: %s", err)
+	}
+}
+
+// TestServicesPoolNextServiceE tests typed
+// errors returned by NextServiceE
+func TestServicesPoolNextServiceE(t *testing.T) {
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1}, "http://a")
+
+	if _, err := pool.NextServiceE(); !errors.As(err, &ErrPoolNotStarted{}) {
+		t.Errorf("want ErrPoolNotStarted, got %v", err)
+	}
+
+	_ = pool.DiscoverServices()
+
+	srv, err := pool.NextServiceE()
+	if err != nil || srv == nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pool.List().FromHealthyToJail(srv.ID())
+
+	var noHealthy ErrNoHealthyServices
+	if _, err := pool.NextServiceE(); !errors.As(err, &noHealthy) {
+		t.Fatalf("want ErrNoHealthyServices, got %v", err)
+	}
+	if noHealthy.Jailed != 1 || noHealthy.Known != 1 {
+		t.Errorf("unexpected error details: %+v", noHealthy)
+	}
+
+	pool.Close()
+
+	if _, err := pool.NextServiceE(); !errors.As(err, &ErrPoolClosed{}) {
+		t.Errorf("want ErrPoolClosed, got %v", err)
+	}
+}
+
+// TestServicesPoolSaturated tests that selection with and without
+// skip function tells saturated services from skipped ones
+func TestServicesPoolSaturated(t *testing.T) {
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1, MaxConcurrency: 1}, "http://a", "http://b")
+	_ = pool.DiscoverServices()
+
+	a, releaseA, err := pool.AcquireService(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected acquire error: %s", err)
+	}
+	defer releaseA()
+
+	skipA := func(srv service.IService) bool { return srv.ID() == a.ID() }
+	skipAll := func(service.IService) bool { return true }
+
+	if _, err := pool.nextService(context.Background(), skipAll); !errors.As(err, &ErrNoHealthyServices{}) {
+		t.Errorf("want ErrNoHealthyServices for skipped services, got %v", err)
+	}
+
+	_, releaseB, err := pool.AcquireService(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected acquire error: %s", err)
+	}
+	defer releaseB()
+
+	if _, err := pool.NextServiceE(); !errors.As(err, &ErrServicesSaturated{}) {
+		t.Errorf("want ErrServicesSaturated, got %v", err)
+	}
+	if _, err := pool.nextService(context.Background(), skipA); !errors.As(err, &ErrServicesSaturated{}) {
+		t.Errorf("want ErrServicesSaturated with skip function, got %v", err)
+	}
+	if _, _, err := pool.tryAcquireService(context.Background(), skipA); !errors.As(err, &ErrServicesSaturated{}) {
+		t.Errorf("want ErrServicesSaturated from try acquire, got %v", err)
+	}
+}
+
+// TestServicesPoolRestart tests that pool can be closed
+// several times, restarted and shut down
+func TestServicesPoolRestart(t *testing.T) {
+	pool, _ := newStaticPoolWithOpts(&ServicesPoolsOpts{
+		ListOpts:          &ServicesListOpts{TryUpTries: 1, ChecksInterval: 10 * time.Millisecond},
+		DiscoveryInterval: 10 * time.Millisecond,
+	}, "http://a", "http://b")
+
+	pool.Start(true)
+	pool.Start(true)
+	pool.Close()
+	pool.Close()
+
+	pool.Start(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := pool.WaitReady(ctx, 2); err != nil {
+		t.Fatalf("restarted pool is not ready: %s", err)
+	}
+
+	if err := pool.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected shutdown error: %s", err)
+	}
+	if pool.List().CountAll() != 0 {
+		t.Errorf("services are not removed on shutdown")
+	}
+	if _, err := pool.NextServiceE(); !errors.As(err, &ErrPoolClosed{}) {
+		t.Errorf("want ErrPoolClosed after shutdown, got %v", err)
+	}
+}
+
+// loopCountingList is list tracking max number
+// of concurrently running healthchecks loops
+type loopCountingList struct {
+	IServicesList
+	running, max atomic.Int32
+}
+
+func (l *loopCountingList) HealthChecksLoop() {
+	running := l.running.Add(1)
+	defer l.running.Add(-1)
+
+	for {
+		max := l.max.Load()
+		if running <= max || l.max.CompareAndSwap(max, running) {
+			break
+		}
+	}
+
+	l.IServicesList.HealthChecksLoop()
+}
+
+// TestServicesPoolRestartLoops tests that quick restarts
+// never leave several healthchecks loops running
+func TestServicesPoolRestartLoops(t *testing.T) {
+	list := &loopCountingList{IServicesList: NewServicesList("TestServicePool", &ServicesListOpts{
+		ChecksInterval: time.Millisecond,
+	})}
+
+	pool, _ := newStaticPoolWithOpts(&ServicesPoolsOpts{CustomList: list}, "http://a")
+
+	for i := 0; i < 50; i++ {
+		pool.Start(true)
+		pool.Close()
+	}
+	pool.Start(true)
+
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := pool.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected shutdown error: %s", err)
+	}
+	if max := list.max.Load(); max != 1 {
+		t.Errorf("want single healthchecks loop, got %d running at once", max)
+	}
+}
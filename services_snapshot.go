@@ -0,0 +1,121 @@
+package pool
+
+import (
+	"maps"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// Service states used in ServiceSnapshot
+const (
+	StateHealthy = "healthy"
+	StateJailed  = "jailed"
+)
+
+// ListSnapshot is immutable point-in-time
+// state of the services list
+type ListSnapshot struct {
+	Time    time.Time `json:"time"`    // time snapshot was taken
+	Healthy int       `json:"healthy"` // number of healthy services
+	Jailed  int       `json:"jailed"`  // number of jailed services
+	// Cursor is round-robin counter, Next starts looking for
+	// service from the healthy one with (Cursor+1)%Healthy index
+	Cursor uint64 `json:"cursor"`
+	// Services are healthy services in the list
+	// order followed by jailed ones sorted by ID
+	Services []ServiceSnapshot `json:"services"`
+	// Overrides are active manual operator overrides
+	Overrides []Override `json:"overrides,omitempty"`
+}
+
+// ServiceSnapshot is immutable point-in-time
+// state of the service in the list
+type ServiceSnapshot struct {
+	ID       string            `json:"id"`
+	Address  string            `json:"address"`
+	NodeName string            `json:"node_name,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	Meta     map[string]string `json:"meta,omitempty"`
+	Status   string            `json:"status"`    // status reported by service itself
+	State    string            `json:"state"`     // healthy or jailed
+	Draining bool              `json:"draining"`  // service is skipped by Next
+	Excluded bool              `json:"excluded"`  // service is skipped by Next due to overrides
+	InFlight int               `json:"in_flight"` // number of acquired concurrency slots
+	// RateRemaining is number of requests which can be made to
+	// the service right now, nil if service has no rate limit
+	RateRemaining *float64     `json:"rate_remaining,omitempty"`
+	Stats         ServiceStats `json:"stats"`
+}
+
+// Snapshot atomically take point-in-time
+// state of the list and all its services
+func (l *ServicesList) Snapshot() ListSnapshot {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	l.stats.mu.Lock()
+	defer l.stats.mu.Unlock()
+
+	snapshot := ListSnapshot{
+		Time:      time.Now(),
+		Healthy:   len(l.healthy),
+		Jailed:    len(l.jail),
+		Cursor:    atomic.LoadUint64(&l.current),
+		Services:  make([]ServiceSnapshot, 0, len(l.healthy)+len(l.jail)),
+		Overrides: l.activeOverrides(),
+	}
+
+	for _, srv := range l.healthy {
+		snapshot.Services = append(snapshot.Services, l.serviceSnapshot(srv, StateHealthy))
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(l.jail)) {
+		snapshot.Services = append(snapshot.Services, l.serviceSnapshot(l.jail[id], StateJailed))
+	}
+
+	return snapshot
+}
+
+// serviceSnapshot take state of given service, must
+// be called under both list and stats locks
+func (l *ServicesList) serviceSnapshot(srv service.IService, state string) ServiceSnapshot {
+	snapshot := ServiceSnapshot{
+		ID:       srv.ID(),
+		Address:  srv.Address(),
+		NodeName: srv.NodeName(),
+		Tags:     slices.Sorted(maps.Keys(srv.Tags())),
+		Meta:     maps.Clone(srv.Meta()),
+		Status:   srv.Status().String(),
+		State:    state,
+	}
+
+	_, snapshot.Draining = l.draining[srv.ID()]
+	snapshot.Excluded = l.isOverridden(OverrideExclude, srv) ||
+		l.hasPins() && !l.isOverridden(OverridePin, srv)
+
+	snapshot.InFlight = l.inflight[srv.ID()]
+	if remaining, ok := l.rateRemaining(srv, time.Now()); ok {
+		snapshot.RateRemaining = &remaining
+	}
+
+	if stats, ok := l.stats.stats[srv.ID()]; ok {
+		snapshot.Stats = *stats
+	}
+
+	return snapshot
+}
+
+// Routable return number of services
+// which can be selected by Next
+func (s ListSnapshot) Routable() int {
+	var routable int
+	for _, srv := range s.Services {
+		if srv.State == StateHealthy && srv.Status == service.StatusHealthy.String() && !srv.Draining && !srv.Excluded {
+			routable++
+		}
+	}
+	return routable
+}
@@ -0,0 +1,166 @@
+package pool
+
+import (
+	"sync"
+	"time"
+)
+
+// ServiceStats is health statistics
+// of the service in the list
+type ServiceStats struct {
+	LastCheck           time.Time `json:"last_check"`                 // time of the last healthcheck
+	LastCheckError      string    `json:"last_check_error,omitempty"` // error of the last healthcheck if any
+	ConsecutiveFailures int       `json:"consecutive_failures"`       // number of failed healthchecks in a row
+	JailedAt            time.Time `json:"jailed_at"`                  // time the service was moved to jail, zero if not jailed
+	TryUpAttempts       int       `json:"try_up_attempts"`            // number of attempts to try up service from jail
+
+	LastResult      time.Time `json:"last_result"`                 // time of the last reported request result
+	LastResultError string    `json:"last_result_error,omitempty"` // error of the last reported request result if any
+	ResultFailures  int       `json:"result_failures"`             // number of reported failed requests in a row
+
+	LastLatency time.Duration `json:"last_latency"` // latency of the last reported request
+	AvgLatency  time.Duration `json:"avg_latency"`  // moving average of the reported request latencies
+}
+
+// latencyAvgWeight is weight of the new
+// sample in the moving average latency
+const latencyAvgWeight = 0.2
+
+// statsTracker keeps health
+// statistics of the services
+type statsTracker struct {
+	mu    sync.Mutex
+	stats map[string]*ServiceStats
+}
+
+// newStatsTracker create new statsTracker
+func newStatsTracker() *statsTracker {
+	return &statsTracker{stats: make(map[string]*ServiceStats)}
+}
+
+// entry return stats of the service with given
+// ID creating it if needed, must be called under lock
+func (t *statsTracker) entry(id string) *ServiceStats {
+	s, ok := t.stats[id]
+	if !ok {
+		s = &ServiceStats{}
+		t.stats[id] = s
+	}
+	return s
+}
+
+// observeCheck save result of the
+// service healthcheck
+func (t *statsTracker) observeCheck(id string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.entry(id)
+	s.LastCheck = time.Now()
+
+	if err != nil {
+		s.LastCheckError = err.Error()
+		s.ConsecutiveFailures++
+		return
+	}
+
+	s.LastCheckError = ""
+	s.ConsecutiveFailures = 0
+}
+
+// jailed save time the service was moved to jail
+func (t *statsTracker) jailed(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.entry(id)
+	s.JailedAt = time.Now()
+	s.TryUpAttempts = 0
+	s.ResultFailures = 0
+}
+
+// released reset jail
+// stats of the service
+func (t *statsTracker) released(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.entry(id)
+	s.JailedAt = time.Time{}
+	s.TryUpAttempts = 0
+}
+
+// tryUp save number of attempts to
+// try up the service from jail
+func (t *statsTracker) tryUp(id string, attempts int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.entry(id).TryUpAttempts = attempts
+}
+
+// observeResult save reported request result of the service
+// and return number of reported failed requests in a row
+func (t *statsTracker) observeResult(id string, err error) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.entry(id)
+	s.LastResult = time.Now()
+
+	if err != nil {
+		s.LastResultError = err.Error()
+		s.ResultFailures++
+		return s.ResultFailures
+	}
+
+	s.LastResultError = ""
+	s.ResultFailures = 0
+	return 0
+}
+
+// observeLatency save reported latency of the request made
+// to the service, failed requests are observed as well
+func (t *statsTracker) observeLatency(id string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := t.entry(id)
+	s.LastLatency = d
+
+	if s.AvgLatency == 0 {
+		s.AvgLatency = d
+		return
+	}
+	s.AvgLatency += time.Duration(latencyAvgWeight * float64(d-s.AvgLatency))
+}
+
+// forget drop stats of the
+// service removed from the list
+func (t *statsTracker) forget(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.stats, id)
+}
+
+// get return a copy of the
+// service stats if any
+func (t *statsTracker) get(id string) (ServiceStats, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.stats[id]
+	if !ok {
+		return ServiceStats{}, false
+	}
+	return *s, true
+}
+
+// DiscoveryStatus is result of the
+// pool discovery rounds
+type DiscoveryStatus struct {
+	LastSuccess time.Time `json:"last_success"`         // time of the last successful discovery round
+	LastError   string    `json:"last_error,omitempty"` // error of the last failed discovery round if any
+	LastErrorAt time.Time `json:"last_error_at"`        // time of the last failed discovery round
+}
@@ -0,0 +1,225 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// instrumentationName is OpenTelemetry
+// instrumentation scope name
+const instrumentationName = "github.com/gateway-fm/service-pool"
+
+// OpenTelemetry attributes keys
+const (
+	attrPool           = attribute.Key("servicepool.pool")
+	attrServiceID      = attribute.Key("servicepool.service.id")
+	attrServiceAddress = attribute.Key("servicepool.service.address")
+	attrOutcome        = attribute.Key("servicepool.outcome")
+	attrTry            = attribute.Key("servicepool.try")
+)
+
+// Outcomes used as attrOutcome values
+const (
+	outcomeSuccess = "success"
+	outcomeFailure = "failure"
+	outcomeEmpty   = "empty"
+)
+
+// newTracer return tracer of given
+// provider or noop one if it is nil
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+
+	return tp.Tracer(instrumentationName)
+}
+
+// serviceAttributes return OpenTelemetry
+// attributes of given service
+func serviceAttributes(srv service.IService) []attribute.KeyValue {
+	if srv == nil {
+		return nil
+	}
+
+	return []attribute.KeyValue{
+		attrServiceID.String(srv.ID()),
+		attrServiceAddress.String(srv.Address()),
+	}
+}
+
+// endSpan set span outcome and
+// status by given error and end it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(attrOutcome.String(outcomeFailure))
+	} else {
+		span.SetAttributes(attrOutcome.String(outcomeSuccess))
+	}
+
+	span.End()
+}
+
+// otelMetrics is OpenTelemetry implementation
+// of MetricsCollector interface
+type otelMetrics struct {
+	healthy metric.Int64Gauge
+	jailed  metric.Int64Gauge
+
+	discoveryDuration metric.Float64Histogram
+	discoveryErrors   metric.Int64Counter
+
+	healthCheckDuration metric.Float64Histogram
+	healthCheckFailures metric.Int64Counter
+
+	tryUps  metric.Int64Counter
+	nextNil metric.Int64Counter
+}
+
+// newOtelMetrics create OpenTelemetry metrics
+// collector with given meter provider
+func newOtelMetrics(mp metric.MeterProvider) (*otelMetrics, error) {
+	meter := mp.Meter(instrumentationName)
+	m := &otelMetrics{}
+
+	var err error
+	if m.healthy, err = meter.Int64Gauge("servicepool.services.healthy",
+		metric.WithDescription("Number of healthy services in the pool.")); err != nil {
+		return nil, fmt.Errorf("create healthy services gauge: %w", err)
+	}
+	if m.jailed, err = meter.Int64Gauge("servicepool.services.jailed",
+		metric.WithDescription("Number of jailed services in the pool.")); err != nil {
+		return nil, fmt.Errorf("create jailed services gauge: %w", err)
+	}
+	if m.discoveryDuration, err = meter.Float64Histogram("servicepool.discovery.duration",
+		metric.WithDescription("Duration of the pool discovery rounds."), metric.WithUnit("s")); err != nil {
+		return nil, fmt.Errorf("create discovery duration histogram: %w", err)
+	}
+	if m.discoveryErrors, err = meter.Int64Counter("servicepool.discovery.errors",
+		metric.WithDescription("Number of failed pool discovery rounds.")); err != nil {
+		return nil, fmt.Errorf("create discovery errors counter: %w", err)
+	}
+	if m.healthCheckDuration, err = meter.Float64Histogram("servicepool.healthcheck.duration",
+		metric.WithDescription("Duration of the service healthchecks."), metric.WithUnit("s")); err != nil {
+		return nil, fmt.Errorf("create healthcheck duration histogram: %w", err)
+	}
+	if m.healthCheckFailures, err = meter.Int64Counter("servicepool.healthcheck.failures",
+		metric.WithDescription("Number of failed service healthchecks.")); err != nil {
+		return nil, fmt.Errorf("create healthcheck failures counter: %w", err)
+	}
+	if m.tryUps, err = meter.Int64Counter("servicepool.tryup.attempts",
+		metric.WithDescription("Number of attempts to try up service from jail.")); err != nil {
+		return nil, fmt.Errorf("create try up attempts counter: %w", err)
+	}
+	if m.nextNil, err = meter.Int64Counter("servicepool.next.nil",
+		metric.WithDescription("Number of NextService calls returned no service.")); err != nil {
+		return nil, fmt.Errorf("create next nil counter: %w", err)
+	}
+
+	return m, nil
+}
+
+func (m *otelMetrics) SetServicesCount(pool string, healthy, jailed int) {
+	attrs := metric.WithAttributes(attrPool.String(pool))
+	m.healthy.Record(context.Background(), int64(healthy), attrs)
+	m.jailed.Record(context.Background(), int64(jailed), attrs)
+}
+
+func (m *otelMetrics) ObserveDiscovery(pool string, duration time.Duration, err error) {
+	attrs := metric.WithAttributes(attrPool.String(pool))
+	m.discoveryDuration.Record(context.Background(), duration.Seconds(), attrs)
+
+	if err != nil {
+		m.discoveryErrors.Add(context.Background(), 1, attrs)
+	}
+}
+
+func (m *otelMetrics) ObserveHealthCheck(pool string, srv service.IService, duration time.Duration, err error) {
+	attrs := metric.WithAttributes(attrPool.String(pool), attrServiceAddress.String(srv.Address()))
+	m.healthCheckDuration.Record(context.Background(), duration.Seconds(), attrs)
+
+	if err != nil {
+		m.healthCheckFailures.Add(context.Background(), 1, attrs)
+	}
+}
+
+func (m *otelMetrics) IncTryUp(pool string, srv service.IService) {
+	m.tryUps.Add(context.Background(), 1, metric.WithAttributes(attrPool.String(pool), attrServiceAddress.String(srv.Address())))
+}
+
+func (m *otelMetrics) IncNextNil(pool string) {
+	m.nextNil.Add(context.Background(), 1, metric.WithAttributes(attrPool.String(pool)))
+}
+
+func (m *otelMetrics) ForgetService(string, service.IService) {}
+
+// multiMetrics is MetricsCollector that
+// passes metrics to several collectors
+type multiMetrics []MetricsCollector
+
+// newMultiMetrics return collector passing metrics to all
+// given non-nil collectors or noop one if there are none
+func newMultiMetrics(collectors ...MetricsCollector) MetricsCollector {
+	var multi multiMetrics
+	for _, c := range collectors {
+		if c != nil {
+			multi = append(multi, c)
+		}
+	}
+
+	switch len(multi) {
+	case 0:
+		return noopMetrics{}
+	case 1:
+		return multi[0]
+	default:
+		return multi
+	}
+}
+
+func (m multiMetrics) SetServicesCount(pool string, healthy, jailed int) {
+	for _, c := range m {
+		c.SetServicesCount(pool, healthy, jailed)
+	}
+}
+
+func (m multiMetrics) ObserveDiscovery(pool string, duration time.Duration, err error) {
+	for _, c := range m {
+		c.ObserveDiscovery(pool, duration, err)
+	}
+}
+
+func (m multiMetrics) ObserveHealthCheck(pool string, srv service.IService, duration time.Duration, err error) {
+	for _, c := range m {
+		c.ObserveHealthCheck(pool, srv, duration, err)
+	}
+}
+
+func (m multiMetrics) IncTryUp(pool string, srv service.IService) {
+	for _, c := range m {
+		c.IncTryUp(pool, srv)
+	}
+}
+
+func (m multiMetrics) IncNextNil(pool string) {
+	for _, c := range m {
+		c.IncNextNil(pool)
+	}
+}
+
+func (m multiMetrics) ForgetService(pool string, srv service.IService) {
+	for _, c := range m {
+		c.ForgetService(pool, srv)
+	}
+}
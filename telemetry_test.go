@@ -0,0 +1,65 @@
+package pool
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestServicesPoolTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	pool, _ := newStaticPoolWithOpts(&ServicesPoolsOpts{
+		TracerProvider: tp,
+	}, "http://a")
+
+	if err := pool.DiscoverServices(); err != nil {
+		t.Fatalf("unexpected discovery error: %s", err)
+	}
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	srv := pool.NextServiceContext(ctx)
+	parent.End()
+
+	if srv == nil {
+		t.Fatalf("unexpected no healthy services")
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	for _, name := range []string{"servicepool.DiscoverServices", "servicepool.HealthCheck", "servicepool.NextService"} {
+		if _, ok := spans[name]; !ok {
+			t.Errorf("span %s was not recorded", name)
+		}
+	}
+
+	discover, check := spans["servicepool.DiscoverServices"], spans["servicepool.HealthCheck"]
+	if discover != nil && check != nil && check.Parent().SpanID() != discover.SpanContext().SpanID() {
+		t.Errorf("HealthCheck span is not a child of the discovery span")
+	}
+
+	next, ok := spans["servicepool.NextService"]
+	if !ok {
+		t.FailNow()
+	}
+
+	if next.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("NextService span is not a child of the caller span")
+	}
+
+	var address string
+	for _, attr := range next.Attributes() {
+		if attr.Key == attrServiceAddress {
+			address = attr.Value.AsString()
+		}
+	}
+	if address != srv.Address() {
+		t.Errorf("want selected address %q in span attributes, got %q", srv.Address(), address)
+	}
+}
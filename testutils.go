@@ -2,6 +2,8 @@ package pool
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gateway-fm/service-pool/discovery"
@@ -33,6 +35,28 @@ func healthySrvMutationFunc(srv service.IService) (service.IService, error) {
 	}, nil
 }
 
+// closeTrackingService is service
+// which tracks if it is closed
+type closeTrackingService struct {
+	service.IService
+	closed atomic.Bool
+}
+
+func (s *closeTrackingService) Close() error {
+	s.closed.Store(true)
+	return s.IService.Close()
+}
+
+// unhealthyService is service
+// which healthcheck always fails
+type unhealthyService struct {
+	service.IService
+}
+
+func (s *unhealthyService) HealthCheck() error {
+	return fmt.Errorf("service %s is down", s.Address())
+}
+
 func dummyMutationFunc(srv service.IService) (service.IService, error) {
 	return srv, nil
 }
@@ -46,6 +70,19 @@ func newHealthyService(addr string) service.IService {
 	return baseSrv
 }
 
+// waitFor poll given condition until it
+// is true or given timeout is passed
+func waitFor(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return true
+}
+
 func newServicesPool(discoveryInterval time.Duration, hcInterval time.Duration, mutationFunc func(srv service.IService) (service.IService, error)) IServicesPool {
 	manualDisc, _ := discovery.NewManualDiscovery(discovery.TransportHttp, nil, "localhost")
 
@@ -63,3 +100,63 @@ func newServicesPool(discoveryInterval time.Duration, hcInterval time.Duration,
 
 	return NewServicesPool(opts)
 }
+
+// newStaticPool create pool of services with given addresses
+// discovered by static discovery, services are healthy
+func newStaticPool(listOpts *ServicesListOpts, addrs ...string) (*ServicesPool, *staticDiscovery) {
+	return newStaticPoolWithOpts(&ServicesPoolsOpts{ListOpts: listOpts}, addrs...)
+}
+
+// newStaticPoolWithOpts create pool with given options of services
+// with given addresses discovered by static discovery, pool name
+// and mutation function making services healthy are set if empty
+func newStaticPoolWithOpts(opts *ServicesPoolsOpts, addrs ...string) (*ServicesPool, *staticDiscovery) {
+	disc := &staticDiscovery{}
+	disc.SetAddresses(addrs...)
+
+	opts.Discovery = disc
+	if opts.Name == "" {
+		opts.Name = "TestServicePool"
+	}
+	if opts.MutationFnc == nil {
+		opts.MutationFnc = healthySrvMutationFunc
+	}
+
+	return NewServicesPool(opts).(*ServicesPool), disc
+}
+
+// staticDiscovery is discovery with
+// changeable list of addresses
+type staticDiscovery struct {
+	mu        sync.Mutex
+	addresses []string
+	tags      map[string]struct{}
+}
+
+func (d *staticDiscovery) Discover(string) ([]service.IService, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var services []service.IService
+	for _, addr := range d.addresses {
+		services = append(services, service.NewService(addr, "", d.tags))
+	}
+	return services, nil
+}
+
+func (d *staticDiscovery) SetTags(tags ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.tags = make(map[string]struct{})
+	for _, t := range tags {
+		d.tags[t] = struct{}{}
+	}
+}
+
+func (d *staticDiscovery) SetAddresses(addrs ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.addresses = addrs
+}
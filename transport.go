@@ -0,0 +1,180 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+// TransportOpts is options that needs
+// to configure Transport instance
+type TransportOpts struct {
+	Base        http.RoundTripper               // transport to make requests, http.DefaultTransport if nil
+	MaxAttempts int                             // max number of attempts of the idempotent request (default is 3)
+	Backoff     func(attempt int) time.Duration // delay before given retry attempt (no delay if nil)
+
+	// IsIdempotent check if request can be retried on another
+	// service, by default requests with idempotent methods or
+	// Idempotency-Key header are retried
+	IsIdempotent func(req *http.Request) bool
+	// IsFailure check if response means service failure which
+	// is reported to the pool and retried, by default 5xx
+	// status codes are failures
+	IsFailure func(resp *http.Response) bool
+}
+
+// Transport is http.RoundTripper sending every request
+// to the next active service of the pool and retrying
+// idempotent requests on another services
+type Transport struct {
+	pool IServicesPool
+	opts TransportOpts
+}
+
+// NewTransport create new Transport with given
+// services pool and configuration
+func NewTransport(pool IServicesPool, opts *TransportOpts) *Transport {
+	t := &Transport{pool: pool}
+	if opts != nil {
+		t.opts = *opts
+	}
+
+	if t.opts.Base == nil {
+		t.opts.Base = http.DefaultTransport
+	}
+	if t.opts.MaxAttempts <= 0 {
+		t.opts.MaxAttempts = defaultMaxAttempts
+	}
+	if t.opts.IsIdempotent == nil {
+		t.opts.IsIdempotent = isIdempotent
+	}
+	if t.opts.IsFailure == nil {
+		t.opts.IsFailure = func(resp *http.Response) bool {
+			return resp.StatusCode >= http.StatusInternalServerError
+		}
+	}
+
+	return t
+}
+
+// RoundTrip implements http.RoundTripper, request scheme and host are
+// replaced with the service address. Response with failure status of
+// the last attempt is returned as is
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	policy := &RetryPolicy{MaxAttempts: 1, Backoff: t.opts.Backoff}
+	if t.opts.IsIdempotent(req) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil) {
+		policy.MaxAttempts = t.opts.MaxAttempts
+	}
+
+	var (
+		resp     *http.Response
+		attempts int
+	)
+
+	err := t.pool.Do(req.Context(), func(ctx context.Context, srv service.IService) error {
+		if resp != nil {
+			_ = resp.Body.Close()
+			resp = nil
+		}
+
+		out, err := rewriteRequest(req, srv, attempts > 0)
+		attempts++
+		if err != nil {
+			return err
+		}
+
+		start := time.Now()
+		r, err := t.opts.Base.RoundTrip(out)
+		if err != nil {
+			return err
+		}
+
+		// latency of failed responses is reported as well
+		t.pool.ReportLatency(srv, time.Since(start))
+
+		// service slot is kept until response body is read
+		if release := holdSlot(ctx); release != nil {
+			r.Body = &releaseBody{ReadCloser: r.Body, release: release}
+		}
+		resp = r
+
+		if t.opts.IsFailure(r) {
+			return ErrServerStatus{Address: srv.Address(), Code: r.StatusCode}
+		}
+		return nil
+	}, policy)
+
+	if resp != nil {
+		return resp, nil
+	}
+	return nil, err
+}
+
+// releaseBody is response body releasing
+// service slot when it is closed
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close close the body and release service slot
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// rewriteRequest return copy of given request sent to given
+// service address, body is recreated for retry attempts
+func rewriteRequest(req *http.Request, srv service.IService, retry bool) (*http.Request, error) {
+	address := srv.Address()
+	if !strings.Contains(address, "://") {
+		scheme := req.URL.Scheme
+		if scheme == "" {
+			scheme = "http"
+		}
+		address = scheme + "://" + address
+	}
+
+	target, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("parse service address %s: %w", srv.Address(), err)
+	}
+
+	out := req.Clone(req.Context())
+	out.URL.Scheme = target.Scheme
+	out.URL.Host = target.Host
+	out.Host = ""
+
+	if target.Path != "" && target.Path != "/" {
+		out.URL.Path = strings.TrimSuffix(target.Path, "/") + "/" + strings.TrimPrefix(req.URL.Path, "/")
+		out.URL.RawPath = ""
+	}
+
+	if retry && req.GetBody != nil {
+		if out.Body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("get request body: %w", err)
+		}
+	}
+
+	return out, nil
+}
+
+// isIdempotent check if request has idempotent
+// method or idempotency key header
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	_, hasKey := req.Header["Idempotency-Key"]
+	_, hasXKey := req.Header["X-Idempotency-Key"]
+	return hasKey || hasXKey
+}
@@ -0,0 +1,100 @@
+package pool
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gateway-fm/service-pool/service"
+)
+
+func TestTransport(t *testing.T) {
+	var failed, ok atomic.Int32
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		failed.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(append([]byte(r.URL.Path+" "), body...))
+	}))
+	defer healthy.Close()
+
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1}, failing.URL, healthy.URL)
+	_ = pool.DiscoverServices()
+
+	client := &http.Client{Transport: NewTransport(pool, nil)}
+
+	for i := 0; i < 4; i++ {
+		resp, err := client.Post("http://service/rpc", "application/json", strings.NewReader("body"))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if resp.StatusCode != http.StatusBadGateway {
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != "/rpc body" {
+				t.Errorf("unexpected response body %q", body)
+			}
+		}
+		_ = resp.Body.Close()
+	}
+
+	if failed.Load() != 2 || ok.Load() != 2 {
+		t.Errorf("want not idempotent requests to be sent once, got %d failed and %d ok", failed.Load(), ok.Load())
+	}
+
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get("http://service/")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("idempotent request was not retried, got status %d", resp.StatusCode)
+		}
+	}
+
+	// latency is reported for failed responses as well
+	for _, addr := range []string{failing.URL, healthy.URL} {
+		if stats, _ := pool.List().Stats(service.NewService(addr, "", nil).ID()); stats.LastLatency <= 0 || stats.AvgLatency <= 0 {
+			t.Errorf("latency of service %s is not reported", addr)
+		}
+	}
+}
+
+func TestTransportHoldsSlot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	pool, _ := newStaticPool(&ServicesListOpts{TryUpTries: 1}, server.URL)
+	_ = pool.DiscoverServices()
+
+	id := pool.List().Healthy()[0].ID()
+	client := &http.Client{Transport: NewTransport(pool, nil)}
+
+	resp, err := client.Get("http://service/")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := pool.List().InFlight(id); n != 1 {
+		t.Errorf("want slot to be held until body is closed, got %d in flight", n)
+	}
+
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	_ = resp.Body.Close()
+
+	if n := pool.List().InFlight(id); n != 0 {
+		t.Errorf("want slot to be released after body is closed, got %d in flight", n)
+	}
+}
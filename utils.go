@@ -18,6 +18,20 @@ func Sleep(t time.Duration, cancelCh <-chan struct{}) {
 	}
 }
 
+// serviceLogAttrs return structured
+// logger key-value pairs of given service
+func serviceLogAttrs(srv service.IService) []any {
+	if srv == nil {
+		return nil
+	}
+
+	return []any{
+		"service_id", srv.ID(),
+		"node", srv.NodeName(),
+		"address", srv.Address(),
+	}
+}
+
 // deleteFromSlice delete item with
 // given index from provided slice
 func deleteFromSlice(slice []service.IService, index int) []service.IService {